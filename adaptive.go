@@ -0,0 +1,116 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// Adaptive provides options for intervals adapting to the observed latency of the operation,
+// so that the backpressure of a slow dependency informs how long to wait.
+// You can set empty for any fields, it will use default values.
+//
+// The Do helpers observe the duration of every attempt. In a Next loop, call Observe.
+//
+// An interval can be computed by this expression.
+//
+// interval = min(max, latency * multiplier), or base before any latency is observed
+type Adaptive struct {
+	// Context is for timeout or canceling retry loop. Default is 1 minute timeout.
+	Context context.Context
+	// Deadline is the time after which no retry is performed. Default is no deadline.
+	// The interval before the last retry is shortened not to sleep past it.
+	// It also bounds the context DoContext passes unless Context is given, which is passed as is.
+	Deadline time.Time
+	// MaxElapsedTime stops retrying once it has elapsed since the first attempt.
+	// Default is 0, no limit. The interval before the last retry is shortened not to exceed it.
+	MaxElapsedTime time.Duration
+	// InitialDelay is the wait duration before the first attempt,
+	// e.g. to stagger startup across a fleet. Default is 0, no wait.
+	// It extends the default timeout rather than consuming it.
+	InitialDelay time.Duration
+	// Base is the wait duration until a latency is observed. Default is 1 second. It is capped by Max.
+	Base time.Duration
+	// Multiplier scales the latency of the latest attempt into the interval. Default is 2.
+	Multiplier float64
+	// Max is the maximum wait duration to retry. Default is 15 seconds.
+	Max time.Duration
+	// MaxAttempts is the maximum number of retries. Default is 0.
+	// If set 0, it will prioritize timeout. Otherwise it disables only the default timeout,
+	// and whichever of MaxAttempts and Context comes first stops retrying.
+	MaxAttempts int
+
+	// latency is the latest observed duration of an attempt.
+	latency time.Duration
+}
+
+func (a *Adaptive) calc() time.Duration {
+	if a.latency == 0 {
+		return a.Base
+	}
+	d := time.Duration(float64(a.latency) * a.Multiplier)
+	if d < 0 || a.Max < d {
+		// A negative duration means it overflowed.
+		return a.Max
+	}
+	return d
+}
+
+func (a *Adaptive) observe(d time.Duration) {
+	a.latency = d
+}
+
+func (a *Adaptive) reset() {
+	a.latency = 0
+}
+
+func (a *Adaptive) maxInterval() time.Duration {
+	return a.Max
+}
+
+func (a *Adaptive) setMax(max time.Duration) {
+	a.Max = max
+	*a = a.withDefaults()
+}
+
+func (a Adaptive) withDefaults() Adaptive {
+	if a.Base == 0 {
+		a.Base = time.Second
+	}
+	if a.Multiplier == 0 {
+		a.Multiplier = 2
+	}
+	if a.Max == 0 {
+		a.Max = 15 * time.Second
+	}
+	if a.Max < a.Base {
+		a.Base = a.Max
+	}
+	return a
+}
+
+func (a Adaptive) new() retrier {
+	a = a.withDefaults()
+	return retrier{
+		calculator:   &a,
+		ctx:          a.Context,
+		deadline:     a.Deadline,
+		maxElapsed:   a.MaxElapsedTime,
+		initialDelay: a.InitialDelay,
+		maxAttempts:  a.MaxAttempts,
+	}
+}
+
+// observer is implemented by calculators that adapt to the latency of attempts.
+type observer interface {
+	observe(d time.Duration)
+}
+
+// Observe feeds the duration of the latest attempt to an algorithm adapting to it, e.g. Adaptive.
+// It has no effect on other algorithms.
+func (r *retrier) Observe(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if o, ok := r.calculator.(observer); ok {
+		o.observe(d)
+	}
+}
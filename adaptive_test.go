@@ -0,0 +1,84 @@
+package retry
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAdaptive_calc(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		latency  time.Duration
+		expected time.Duration
+	}{
+		{
+			name:     "base before any latency is observed",
+			expected: 500 * time.Millisecond,
+		},
+		{
+			name:     "multiple of the latency",
+			latency:  300 * time.Millisecond,
+			expected: 900 * time.Millisecond,
+		},
+		{
+			name:     "capped by max",
+			latency:  time.Minute,
+			expected: 10 * time.Second,
+		},
+		{
+			name:     "overflow",
+			latency:  time.Duration(1 << 62),
+			expected: 10 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := New(Adaptive{
+				Base:       500 * time.Millisecond,
+				Multiplier: 3,
+				Max:        10 * time.Second,
+			})
+			if tt.latency != 0 {
+				r.Observe(tt.latency)
+			}
+			if d := r.calc(); d != tt.expected {
+				t.Fatalf("expected %s, actual: %s", tt.expected, d)
+			}
+		})
+	}
+}
+
+func TestAdaptive_observedByDo(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{}
+	latencies := []time.Duration{time.Second, 3 * time.Second, 2 * time.Second}
+	attempt := 0
+	_ = Do(Adaptive{MaxAttempts: 3}, func() error {
+		// The attempt takes its latency on the fake clock.
+		<-clock.After(latencies[attempt])
+		attempt++
+		return errTest
+	}, WithClock(clock))
+	expected := []time.Duration{
+		time.Second, 2 * time.Second,
+		3 * time.Second, 6 * time.Second,
+		2 * time.Second,
+	}
+	if !reflect.DeepEqual(clock.waits, expected) {
+		t.Fatalf("expected latencies and intervals %v, actual: %v", expected, clock.waits)
+	}
+}
+
+func TestAdaptive_Progress(t *testing.T) {
+	t.Parallel()
+	r := New(Adaptive{})
+	r.Observe(5 * time.Second)
+	r.Progress()
+	if d := r.calc(); d != time.Second {
+		t.Fatalf("expected to start over from base, actual: %s", d)
+	}
+}
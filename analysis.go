@@ -0,0 +1,102 @@
+package retry
+
+import (
+	"math"
+	"time"
+)
+
+// JitterSpread samples the interval an algorithm waits before the given attempt
+// and reports the mean and standard deviation of the samples.
+// A larger standard deviation means the algorithm de-synchronizes clients better.
+//
+// attempt starts at 1, which is the interval before the first retry.
+func JitterSpread(a Algorithm, attempt, samples int) (mean, stddev time.Duration) {
+	if attempt < 1 || samples < 1 {
+		return 0, 0
+	}
+	ds := make([]float64, samples)
+	var sum float64
+	for i := range ds {
+		r := a.new()
+		var d time.Duration
+		for j := 0; j < attempt; j++ {
+			d = r.calc()
+		}
+		ds[i] = float64(d)
+		sum += ds[i]
+	}
+	m := sum / float64(samples)
+	var variance float64
+	for _, d := range ds {
+		variance += (d - m) * (d - m)
+	}
+	variance /= float64(samples)
+	return time.Duration(m), time.Duration(math.Sqrt(variance))
+}
+
+// Preview returns the first n intervals an algorithm waits between attempts without sleeping,
+// e.g. to tune Base and Max before deploying. Jittered algorithms draw from their random source,
+// so previews show the spread unless Key or Rand is given.
+func Preview(a Algorithm, n int) []time.Duration {
+	if n < 1 {
+		return nil
+	}
+	r := a.new()
+	ds := make([]time.Duration, n)
+	for i := range ds {
+		ds[i] = r.calc()
+	}
+	return ds
+}
+
+// Amplification returns the expected number of attempts per operation
+// when every attempt fails independently with failureRate, e.g. 0.1 for 10%.
+// It tells how much a policy amplifies load on a dependency under sustained failures.
+//
+// The number of attempts is capped by MaxAttempts, otherwise by how many attempts
+// fit in the deadline of the Context, the Deadline, MaxElapsedTime or the default timeout
+// following the schedule of the algorithm.
+func Amplification(a Algorithm, failureRate float64) float64 {
+	n := attemptCap(a)
+	if failureRate >= 1 {
+		return n
+	}
+	// The sum of the geometric series 1 + p + p^2 + ... + p^(n-1).
+	return (1 - math.Pow(failureRate, n)) / (1 - failureRate)
+}
+
+// attemptCap returns the maximum number of attempts a retrier created from a can perform.
+// It returns +Inf if nothing caps attempts.
+func attemptCap(a Algorithm) float64 {
+	r := a.new()
+	if r.maxAttempts != 0 {
+		return float64(r.maxAttempts)
+	}
+	budget := time.Duration(math.MaxInt64)
+	if r.ctx == nil && r.deadline.IsZero() && r.maxElapsed == 0 {
+		budget = defaultTimeoutDuration
+	}
+	if r.maxElapsed != 0 {
+		budget = r.maxElapsed
+	}
+	if r.ctx != nil {
+		if d, ok := r.ctx.Deadline(); ok && time.Until(d) < budget {
+			budget = time.Until(d)
+		}
+	}
+	if !r.deadline.IsZero() {
+		if d := time.Until(r.deadline); d < budget {
+			budget = d
+		}
+	}
+	if budget == math.MaxInt64 {
+		return math.Inf(1)
+	}
+	// Limit iterations in case intervals are too short to fill the budget.
+	const limit = 1 << 20
+	n := 1.0
+	for elapsed := r.calc(); elapsed < budget && n < limit; elapsed += r.calc() {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,173 @@
+package retry
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestJitterSpread(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		algorithm   Algorithm
+		attempt     int
+		minMean     time.Duration
+		maxMean     time.Duration
+		leastStddev time.Duration
+		mostStddev  time.Duration
+	}{
+		{
+			name:      "constant never spreads",
+			algorithm: Constant{Interval: time.Second},
+			attempt:   5,
+			minMean:   time.Second,
+			maxMean:   time.Second,
+		},
+		{
+			name: "constant jitter spreads around the interval",
+			algorithm: ConstantJitter{
+				Interval: 10 * time.Second,
+				Jitter:   5 * time.Second,
+			},
+			attempt:     5,
+			minMean:     9 * time.Second,
+			maxMean:     11 * time.Second,
+			leastStddev: 2 * time.Second,
+			mostStddev:  4 * time.Second,
+		},
+		{
+			name: "exponential backoff spreads at attempt 5",
+			algorithm: ExponentialBackoff{
+				Base: time.Second,
+				Max:  time.Hour,
+			},
+			attempt:     5,
+			minMean:     16 * time.Second,
+			maxMean:     32 * time.Second,
+			leastStddev: 2 * time.Second,
+			mostStddev:  16 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mean, stddev := JitterSpread(tt.algorithm, tt.attempt, 10000)
+			t.Logf("mean: %s, stddev: %s", mean, stddev)
+			if mean < tt.minMean || tt.maxMean < mean {
+				t.Fatalf("expected mean to be within [%s, %s], actual: %s", tt.minMean, tt.maxMean, mean)
+			}
+			if stddev < tt.leastStddev || tt.mostStddev < stddev {
+				t.Fatalf("expected stddev to be within [%s, %s], actual: %s", tt.leastStddev, tt.mostStddev, stddev)
+			}
+		})
+	}
+}
+
+func TestAmplification(t *testing.T) {
+	// Not parallel because other tests overwrite the default timeout.
+	tests := []struct {
+		name        string
+		algorithm   Algorithm
+		failureRate float64
+		expected    float64
+	}{
+		{
+			name:        "never fails",
+			algorithm:   Constant{MaxAttempts: 3},
+			failureRate: 0,
+			expected:    1,
+		},
+		{
+			name:        "fails half of the time",
+			algorithm:   Constant{MaxAttempts: 3},
+			failureRate: 0.5,
+			expected:    1.75,
+		},
+		{
+			name:        "always fails",
+			algorithm:   Constant{MaxAttempts: 3},
+			failureRate: 1,
+			expected:    3,
+		},
+		{
+			name:        "capped by the default timeout",
+			algorithm:   Constant{Interval: 10 * time.Second},
+			failureRate: 1,
+			expected:    6,
+		},
+		{
+			name: "capped by the deadline",
+			algorithm: Constant{
+				Interval: 10 * time.Second,
+				Deadline: time.Now().Add(25 * time.Second),
+			},
+			failureRate: 1,
+			expected:    3,
+		},
+		{
+			name:        "uncapped",
+			algorithm:   Constant{Context: context.Background()},
+			failureRate: 0.5,
+			expected:    2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := Amplification(tt.algorithm, tt.failureRate); math.Abs(actual-tt.expected) > 1e-9 {
+				t.Fatalf("expected %v, actual: %v", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPreview(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		algorithm Algorithm
+		n         int
+		expected  []time.Duration
+	}{
+		{
+			name:      "constant",
+			algorithm: Constant{Interval: time.Second},
+			n:         3,
+			expected:  []time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			name:      "fibonacci",
+			algorithm: Fibonacci{Base: time.Second, Max: 4 * time.Second},
+			n:         6,
+			expected: []time.Duration{
+				time.Second,
+				time.Second,
+				2 * time.Second,
+				3 * time.Second,
+				4 * time.Second,
+				4 * time.Second,
+			},
+		},
+		{
+			name:      "none",
+			algorithm: Constant{},
+			n:         0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := Preview(tt.algorithm, tt.n); !reflect.DeepEqual(actual, tt.expected) {
+				t.Fatalf("expected %v, actual: %v", tt.expected, actual)
+			}
+		})
+	}
+	// Previews of a jittered algorithm follow the same draws as a retrier.
+	a := Jitter{Key: "user:1"}
+	r := New(a)
+	for i, d := range Preview(a, 5) {
+		if expected := r.calc(); d != expected {
+			t.Fatalf("interval %d, expected %s, actual: %s", i, expected, d)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned by the Do helpers when the circuit breaker refuses the first attempt.
+var ErrBreakerOpen = errors.New("retry: circuit breaker is open")
+
+// Breaker is a circuit breaker consulted by the Do helpers before every attempt.
+// While it refuses attempts, they return immediately without calling the function,
+// which keeps a dependency that is clearly down from being hammered by retries.
+//
+// A Breaker is typically shared among many calls of the same dependency,
+// so implementations should be safe for concurrent use.
+type Breaker interface {
+	// Allow reports whether an attempt may be performed.
+	Allow() bool
+	// Record is called with the outcome of every attempt performed.
+	Record(success bool)
+}
+
+// WithBreaker makes the Do helpers consult b before every attempt, and before waiting for it,
+// so that no time is spent waiting while b is open.
+// If b refuses the first attempt, they return ErrBreakerOpen, otherwise the last error.
+func WithBreaker(b Breaker) Option {
+	return func(r *retrier) {
+		r.breaker = b
+	}
+}
+
+// ConsecutiveBreaker is a Breaker which opens after consecutive failures.
+// Once cooldown has passed since it opened, it allows a single trial attempt
+// and closes if the trial succeeds, otherwise opens again.
+// It is safe for concurrent use.
+type ConsecutiveBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	// trial is set while the trial attempt after cooldown is in flight.
+	trial bool
+	// now can be replaced for testing.
+	now func() time.Time
+}
+
+// NewConsecutiveBreaker creates a ConsecutiveBreaker which opens after threshold consecutive failures
+// and stays open for cooldown.
+func NewConsecutiveBreaker(threshold int, cooldown time.Duration) *ConsecutiveBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &ConsecutiveBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether the breaker is closed, or allows the trial attempt after cooldown.
+func (b *ConsecutiveBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if b.trial || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.trial = true
+	return true
+}
+
+// releaser is implemented by breakers that reserve an attempt they allowed,
+// so that the Do helpers give it back when the loop stops before performing it.
+type releaser interface {
+	release()
+}
+
+// release gives back the trial attempt the breaker allowed if any.
+func (b *ConsecutiveBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// Record closes the breaker on success, or counts a failure and opens it at the threshold.
+func (b *ConsecutiveBreaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+	if success {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.threshold <= b.failures {
+		b.openedAt = b.now()
+	}
+}
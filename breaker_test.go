@@ -0,0 +1,104 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConsecutiveBreaker(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	b := NewConsecutiveBreaker(2, time.Minute)
+	b.now = func() time.Time { return now }
+	steps := []struct {
+		name    string
+		advance time.Duration
+		fail    bool
+		succeed bool
+		allowed bool
+	}{
+		{name: "closed", allowed: true},
+		{name: "a failure below the threshold", fail: true, allowed: true},
+		{name: "opened at the threshold", fail: true, allowed: false},
+		{name: "within cooldown", advance: 30 * time.Second, allowed: false},
+		{name: "trial after cooldown", advance: 30 * time.Second, allowed: true},
+		{name: "only one trial", allowed: false},
+		{name: "failed trial opens again", fail: true, allowed: false},
+		{name: "second trial", advance: time.Minute, allowed: true},
+		{name: "succeeded trial closes", succeed: true, allowed: true},
+	}
+	for _, s := range steps {
+		now = now.Add(s.advance)
+		if s.fail || s.succeed {
+			b.Record(s.succeed)
+		}
+		if allowed := b.Allow(); allowed != s.allowed {
+			t.Fatalf("%s: expected allowed to be %v, actual: %v", s.name, s.allowed, allowed)
+		}
+	}
+}
+
+func TestWithBreaker(t *testing.T) {
+	t.Parallel()
+	b := NewConsecutiveBreaker(3, time.Hour)
+	attempts := 0
+	fail := func() error {
+		attempts++
+		return errTest
+	}
+	res, err := DoResult(Constant{MaxAttempts: 5}, fail, WithBreaker(b), WithClock(&fakeClock{}))
+	if !errors.Is(err, errTest) {
+		t.Fatalf("expected the last error, actual: %v", err)
+	}
+	if attempts != 3 || res.StopReason != BreakerOpen {
+		t.Fatalf("expected to stop by %s after 3 attempts, actual: %s after %d", BreakerOpen, res.StopReason, attempts)
+	}
+	err = Do(Constant{MaxAttempts: 5}, fail, WithBreaker(b), WithClock(&fakeClock{}))
+	if !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("expected %v, actual: %v", ErrBreakerOpen, err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected no attempt while the breaker is open, actual: %d", attempts)
+	}
+}
+
+func TestWithBreaker_noWaitWhileOpen(t *testing.T) {
+	t.Parallel()
+	b := NewConsecutiveBreaker(2, time.Hour)
+	clock := &fakeClock{}
+	_ = Do(Constant{MaxAttempts: 5}, failN(5, errTest), WithBreaker(b), WithClock(clock))
+	expected := []time.Duration{time.Second}
+	if !reflect.DeepEqual(clock.waits, expected) {
+		t.Fatalf("expected no wait once the breaker opened, expected: %v, actual: %v", expected, clock.waits)
+	}
+	clock = &fakeClock{}
+	_ = Do(Constant{MaxAttempts: 5, InitialDelay: time.Second}, failN(5, errTest), WithBreaker(b), WithClock(clock))
+	if len(clock.waits) != 0 {
+		t.Fatalf("expected no initial delay while the breaker is open, actual: %v", clock.waits)
+	}
+}
+
+func TestWithBreaker_release(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	b := NewConsecutiveBreaker(1, time.Minute)
+	b.now = func() time.Time { return now }
+	b.Record(false)
+	now = now.Add(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// The trial is allowed, but the loop stops while waiting for it.
+	res, err := DoResult(Constant{Context: ctx, InitialDelay: time.Hour}, failN(1, errTest), WithBreaker(b))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, actual: %v", context.Canceled, err)
+	}
+	if res.Attempts != 0 || res.StopReason != ContextCanceled {
+		t.Fatalf("expected to stop by %s before the trial, actual: %s after %d attempts", ContextCanceled, res.StopReason, res.Attempts)
+	}
+	if !b.Allow() {
+		t.Fatal("expected the trial not performed to be given back")
+	}
+}
@@ -0,0 +1,83 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// Budget is a token bucket of retries shared among retriers.
+// Every retry consumes a token and tokens refill over time or by calls,
+// so a burst of failures doesn't permanently exhaust retries
+// while sustained failures can't turn into a retry storm.
+//
+// Budget is a Guard, pass it to WithGuard. It is safe for concurrent use.
+type Budget struct {
+	mu       sync.Mutex
+	capacity float64
+	refill   float64
+	// ratio is the tokens deposited by every first attempt.
+	ratio  float64
+	tokens float64
+	last   time.Time
+	// now can be replaced for testing.
+	now func() time.Time
+}
+
+// NewRefillingBudget creates a Budget which starts full with capacity tokens
+// and refills refillPerSecond tokens per second up to capacity.
+func NewRefillingBudget(capacity int, refillPerSecond float64) *Budget {
+	return &Budget{
+		capacity: float64(capacity),
+		refill:   refillPerSecond,
+		tokens:   float64(capacity),
+		now:      time.Now,
+	}
+}
+
+// NewRatioBudget creates a Budget which starts full with capacity tokens
+// and deposits ratio tokens for every first attempt up to capacity,
+// so that retries stay within ratio of the calls, e.g. 0.1 for at most 10% extra load,
+// like the retry throttling of gRPC. capacity allows a burst of retries
+// while the calls are too few to earn them.
+func NewRatioBudget(capacity int, ratio float64) *Budget {
+	return &Budget{
+		capacity: float64(capacity),
+		ratio:    ratio,
+		tokens:   float64(capacity),
+		now:      time.Now,
+	}
+}
+
+// deposit earns tokens for a first attempt.
+func (b *Budget) deposit() {
+	if b.ratio == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += b.ratio
+	if b.capacity < b.tokens {
+		b.tokens = b.capacity
+	}
+}
+
+// AllowRetry consumes a token if available.
+func (b *Budget) AllowRetry() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	if !b.last.IsZero() {
+		if elapsed := now.Sub(b.last); 0 < elapsed {
+			b.tokens += elapsed.Seconds() * b.refill
+			if b.capacity < b.tokens {
+				b.tokens = b.capacity
+			}
+		}
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
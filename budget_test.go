@@ -0,0 +1,94 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBudget_AllowRetry(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	b := NewRefillingBudget(3, 2)
+	b.now = func() time.Time { return now }
+	allowed := func() int {
+		n := 0
+		for b.AllowRetry() {
+			n++
+		}
+		return n
+	}
+	if n := allowed(); n != 3 {
+		t.Fatalf("expected to allow 3 retries from full capacity, actual: %d", n)
+	}
+	now = now.Add(time.Second)
+	if n := allowed(); n != 2 {
+		t.Fatalf("expected to allow 2 retries after refilling for a second, actual: %d", n)
+	}
+	now = now.Add(time.Hour)
+	if n := allowed(); n != 3 {
+		t.Fatalf("expected to refill up to capacity, actual: %d", n)
+	}
+}
+
+func TestBudget_sharedByDo(t *testing.T) {
+	t.Parallel()
+	b := NewRefillingBudget(3, 0)
+	attempts := 0
+	for i := 0; i < 2; i++ {
+		_ = Do(Constant{
+			Interval:    time.Microsecond,
+			MaxAttempts: 3,
+		}, func() error {
+			attempts++
+			return errors.New("error")
+		}, WithGuard(b))
+	}
+	// 2 first attempts and 3 retries allowed by the budget.
+	if attempts != 5 {
+		t.Fatalf("expected to attempt 5 times, actual: %d", attempts)
+	}
+}
+
+func TestRatioBudget(t *testing.T) {
+	t.Parallel()
+	b := NewRatioBudget(2, 0.25)
+	attempts := 0
+	for i := 0; i < 30; i++ {
+		_ = Do(Constant{MaxAttempts: 3}, func() error {
+			attempts++
+			return errors.New("error")
+		}, WithGuard(b), WithClock(&fakeClock{}))
+	}
+	// 30 first attempts, 2 retries of the initial burst
+	// and 7 retries earned by the 29 calls after the burst.
+	if attempts != 39 {
+		t.Fatalf("expected to attempt 39 times, actual: %d", attempts)
+	}
+}
+
+func TestRatioBudget_concurrent(t *testing.T) {
+	t.Parallel()
+	b := NewRatioBudget(0, 0.5)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	attempts := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = Do(Constant{MaxAttempts: 2}, func() error {
+				mu.Lock()
+				attempts++
+				mu.Unlock()
+				return errors.New("error")
+			}, WithGuard(b), WithClock(&fakeClock{}))
+		}()
+	}
+	wg.Wait()
+	// Retries never exceed half of the 100 calls.
+	if attempts < 100 || 150 < attempts {
+		t.Fatalf("expected 100 to 150 attempts, actual: %d", attempts)
+	}
+}
@@ -0,0 +1,17 @@
+package retry
+
+// Chain wraps Next of r with middlewares for cross-cutting concerns
+// such as logging, metrics and rate limiting.
+// A middleware receives the next Next in the chain and returns a new one,
+// which can observe the result or short-circuit it by returning false without calling next.
+// The first middleware is the outermost. Calling Chain again wraps the existing chain.
+func Chain(r *retrier, mw ...func(next func() bool) func() bool) {
+	next := r.chained
+	if next == nil {
+		next = r.advance
+	}
+	for i := len(mw) - 1; 0 <= i; i-- {
+		next = mw[i](next)
+	}
+	r.chained = next
+}
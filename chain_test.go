@@ -0,0 +1,49 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+	var calls, vetoes int
+	count := func(next func() bool) func() bool {
+		return func() bool {
+			calls++
+			return next()
+		}
+	}
+	vetoAfter3 := func(next func() bool) func() bool {
+		attempts := 0
+		return func() bool {
+			if attempts == 3 {
+				vetoes++
+				return false
+			}
+			attempts++
+			return next()
+		}
+	}
+	r := New(Constant{
+		Interval:    time.Millisecond,
+		MaxAttempts: 5,
+	})
+	Chain(r, count, vetoAfter3)
+	attempts := 0
+	for r.Next() {
+		attempts++
+	}
+	if attempts != 3 {
+		t.Fatalf("expected to be vetoed after 3 attempts, actual: %d", attempts)
+	}
+	if calls != 4 {
+		t.Fatalf("expected the outer middleware to observe 4 calls, actual: %d", calls)
+	}
+	if vetoes != 1 {
+		t.Fatalf("expected to veto once, actual: %d", vetoes)
+	}
+	if r.attempts != 3 {
+		t.Fatalf("expected the vetoed call not to reach the retrier, actual: %v attempts", r.attempts)
+	}
+}
@@ -0,0 +1,96 @@
+package retry
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Decision is how the Do helpers react to an error returned by the function.
+type Decision int
+
+const (
+	// RetryWithBackoff retries after the interval of the algorithm.
+	RetryWithBackoff Decision = iota
+	// RetryNow retries immediately without waiting, e.g. to fail over
+	// to another endpoint when the error says the current one is bad.
+	RetryNow
+	// NoRetry stops retrying and returns the error.
+	NoRetry
+)
+
+// Policy decides whether and how errors are retried apart from the retry loop,
+// so that the decision can be tested without running a loop.
+type Policy struct {
+	// RetryIf reports whether an error is worth retrying, e.g. false for a 400 Bad Request.
+	// It is consulted before Classify. Default retries every error.
+	RetryIf func(err error) bool
+	// Classify decides how to react to an error. Default retries every error with backoff.
+	Classify func(err error) Decision
+}
+
+// Decide returns how the Do helpers react to err.
+// A nil error and an error wrapped by Permanent are never retried.
+func (p Policy) Decide(err error) Decision {
+	var perm *PermanentError
+	if err == nil || errors.As(err, &perm) {
+		return NoRetry
+	}
+	if p.RetryIf != nil && !p.RetryIf(err) {
+		return NoRetry
+	}
+	if p.Classify == nil {
+		return RetryWithBackoff
+	}
+	return p.Classify(err)
+}
+
+// WouldRetry reports whether the Do helpers retry err.
+func (p Policy) WouldRetry(err error) bool {
+	return p.Decide(err) != NoRetry
+}
+
+// WithPolicy makes the Do helpers decide how to react to every error by p.
+func WithPolicy(p Policy) Option {
+	return func(r *retrier) {
+		r.policy = p
+	}
+}
+
+// WithRetryIf makes the Do helpers stop immediately and return the error
+// if retryable reports that it is not worth retrying.
+func WithRetryIf(retryable func(err error) bool) Option {
+	return func(r *retrier) {
+		r.policy.RetryIf = retryable
+	}
+}
+
+// WithClassifier makes the Do helpers decide how to react to every error by classify.
+// By default, every error is retried with backoff.
+func WithClassifier(classify func(err error) Decision) Option {
+	return func(r *retrier) {
+		r.policy.Classify = classify
+	}
+}
+
+// RetryOn makes the Do helpers retry only errors matching any of targets and return the others immediately,
+// e.g. RetryOn(io.ErrUnexpectedEOF, (*net.OpError)(nil)).
+// A target matches by errors.Is, while a nil pointer target matches any error of its type by errors.As.
+// It sets Policy.RetryIf, so it replaces WithRetryIf and vice versa. Permanent errors are never retried.
+func RetryOn(targets ...error) Option {
+	return WithRetryIf(func(err error) bool {
+		for _, target := range targets {
+			if matchError(err, target) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// matchError reports whether err matches target as described in RetryOn.
+func matchError(err, target error) bool {
+	if v := reflect.ValueOf(target); v.Kind() == reflect.Pointer && v.IsNil() {
+		return errors.As(err, reflect.New(v.Type()).Interface())
+	}
+	return errors.Is(err, target)
+}
@@ -0,0 +1,124 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWithClassifier(t *testing.T) {
+	t.Parallel()
+	errBadEndpoint := errors.New("bad endpoint")
+	errBadRequest := errors.New("bad request")
+	classify := func(err error) Decision {
+		switch {
+		case errors.Is(err, errBadEndpoint):
+			return RetryNow
+		case errors.Is(err, errBadRequest):
+			return NoRetry
+		}
+		return RetryWithBackoff
+	}
+	t.Run("retry now", func(t *testing.T) {
+		interval := 50 * time.Millisecond
+		var calls []time.Time
+		errs := []error{errBadEndpoint, errTest, nil}
+		err := Do(Constant{
+			Interval:    interval,
+			MaxAttempts: 3,
+		}, func() error {
+			calls = append(calls, time.Now())
+			err := errs[0]
+			errs = errs[1:]
+			return err
+		}, WithClassifier(classify))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := calls[1].Sub(calls[0]); interval/2 < d {
+			t.Fatalf("expected to retry immediately after %v, actual: %s later", errBadEndpoint, d)
+		}
+		if d := calls[2].Sub(calls[1]); d < interval {
+			t.Fatalf("expected to retry with backoff after %v, actual: %s later", errTest, d)
+		}
+	})
+	t.Run("no retry", func(t *testing.T) {
+		res, err := DoResult(Constant{
+			Interval:    time.Millisecond,
+			MaxAttempts: 3,
+		}, func() error {
+			return errBadRequest
+		}, WithClassifier(classify))
+		if !errors.Is(err, errBadRequest) {
+			t.Fatalf("expected %v, actual: %v", errBadRequest, err)
+		}
+		if res.Attempts != 1 {
+			t.Fatalf("expected not to retry, actual: %d attempts", res.Attempts)
+		}
+		if res.StopReason != NotRetryable {
+			t.Fatalf("expected to stop by %s, actual: %s", NotRetryable, res.StopReason)
+		}
+	})
+}
+
+type statusError int
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("status %d", int(e))
+}
+
+func TestPolicy_WouldRetry(t *testing.T) {
+	t.Parallel()
+	p := Policy{
+		Classify: func(err error) Decision {
+			var status statusError
+			if errors.As(err, &status) && status < 500 {
+				return NoRetry
+			}
+			return RetryWithBackoff
+		},
+	}
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{err: nil, expected: false},
+		{err: statusError(400), expected: false},
+		{err: fmt.Errorf("wrapped: %w", statusError(404)), expected: false},
+		{err: statusError(503), expected: true},
+		{err: errTest, expected: true},
+	}
+	for _, tt := range tests {
+		if actual := p.WouldRetry(tt.err); actual != tt.expected {
+			t.Fatalf("expected WouldRetry(%v) to be %t, actual: %t", tt.err, tt.expected, actual)
+		}
+	}
+	if (Policy{}).WouldRetry(errTest) != true {
+		t.Fatal("expected the zero Policy to retry every error")
+	}
+}
+
+func TestRetryOn(t *testing.T) {
+	t.Parallel()
+	r := New(Constant{}, RetryOn(io.ErrUnexpectedEOF, (*net.OpError)(nil)))
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "sentinel", err: io.ErrUnexpectedEOF, expected: true},
+		{name: "wrapped sentinel", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), expected: true},
+		{name: "type", err: fmt.Errorf("dial: %w", &net.OpError{Op: "dial", Err: errTest}), expected: true},
+		{name: "other", err: errTest, expected: false},
+		{name: "other sentinel", err: io.EOF, expected: false},
+		{name: "permanent", err: Permanent(io.ErrUnexpectedEOF), expected: false},
+	}
+	for _, tt := range tests {
+		if actual := r.policy.WouldRetry(tt.err); actual != tt.expected {
+			t.Fatalf("%s: expected WouldRetry(%v) to be %t, actual: %t", tt.name, tt.err, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// Clock tells the time and waits for the intervals between retries,
+// e.g. a fake clock drives a whole backoff sequence instantly in tests.
+// The deadline of Context still follows the wall clock.
+// Now is called while the retrier is locked, so it must not call the methods of the retrier.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the wall clock, which is the default Clock.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// WithClock makes the retrier tell the time and wait by c instead of the wall clock.
+func WithClock(c Clock) Option {
+	return func(r *retrier) {
+		r.clock = c
+	}
+}
+
+// WithSleep makes the retrier wait by sleep instead of the clock, e.g. to yield to a cooperative scheduler
+// or to run where timers behave poorly. sleep must return once d has passed or ctx is done.
+// If it returns an error while ctx is not done, the retry loop stops by SleepFailed
+// and the Do helpers return the error along with the last error of the function.
+// The clock still tells the time.
+func WithSleep(sleep func(ctx context.Context, d time.Duration) error) Option {
+	return func(r *retrier) {
+		r.sleepFunc = sleep
+	}
+}
+
+// clockOrReal returns the clock of the retrier, or the wall clock if none is set.
+func (r *retrier) clockOrReal() Clock {
+	if r.clock == nil {
+		return realClock{}
+	}
+	return r.clock
+}
+
+// sleep waits for d by the sleep function or the clock of the retrier.
+// It returns the error of the sleep function, or ctx.Err() if ctx is done first.
+// On the wall clock, the timer is released as soon as ctx is done.
+func (r *retrier) sleep(ctx context.Context, d time.Duration) error {
+	if r.sleepFunc != nil {
+		return r.sleepFunc(ctx, d)
+	}
+	var after <-chan time.Time
+	if r.clock == nil {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		after = timer.C
+	} else {
+		after = r.clock.After(d)
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-after:
+		return nil
+	}
+}
+
+// stopBySleep stops the loop after sleep returned err, by the context if it is done.
+func (r *retrier) stopBySleep(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return r.stop(contextReason(ctx))
+	}
+	r.sleepErr = err
+	return r.stop(SleepFailed)
+}
@@ -0,0 +1,133 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock advances instantly by every duration waited and records it.
+type fakeClock struct {
+	mu    sync.Mutex
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestWithClock(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{}
+	r := New(Linear{
+		Base:        time.Hour,
+		Max:         3 * time.Hour,
+		MaxAttempts: 5,
+	}, WithClock(clock))
+	start := time.Now()
+	for r.Next() {
+	}
+	if elapsed := time.Since(start); time.Second < elapsed {
+		t.Fatalf("expected not to wait on the wall clock, actual: %s", elapsed)
+	}
+	expected := []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour, 3 * time.Hour}
+	if !reflect.DeepEqual(clock.waits, expected) {
+		t.Fatalf("expected to wait %v, actual: %v", expected, clock.waits)
+	}
+	if r.slept != 9*time.Hour {
+		t.Fatalf("expected to sleep 9h in total by the clock, actual: %s", r.slept)
+	}
+}
+
+func TestWithSleep(t *testing.T) {
+	t.Parallel()
+	t.Run("waits by sleep", func(t *testing.T) {
+		var waits []time.Duration
+		r := New(Constant{
+			Interval:    time.Hour,
+			MaxAttempts: 3,
+		}, WithSleep(func(ctx context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		}))
+		attempts := 0
+		for r.Next() {
+			attempts++
+		}
+		if attempts != 3 {
+			t.Fatalf("expected 3 attempts, actual: %d", attempts)
+		}
+		expected := []time.Duration{time.Hour, time.Hour}
+		if !reflect.DeepEqual(waits, expected) {
+			t.Fatalf("expected to wait %v, actual: %v", expected, waits)
+		}
+	})
+	errSleep := errors.New("scheduler closed")
+	t.Run("stops on error", func(t *testing.T) {
+		r := New(Constant{
+			Interval:    time.Hour,
+			MaxAttempts: 3,
+		}, WithSleep(func(ctx context.Context, d time.Duration) error {
+			return errSleep
+		}))
+		attempts := 0
+		for r.Next() {
+			attempts++
+		}
+		if attempts != 1 {
+			t.Fatalf("expected 1 attempt, actual: %d", attempts)
+		}
+		if r.reason != SleepFailed {
+			t.Fatalf("expected to stop by %s, actual: %s", SleepFailed, r.reason)
+		}
+	})
+	t.Run("returns the error by Do", func(t *testing.T) {
+		res, err := DoResult(Constant{
+			Interval:    time.Hour,
+			MaxAttempts: 3,
+		}, failN(3, errTest), WithSleep(func(ctx context.Context, d time.Duration) error {
+			return errSleep
+		}))
+		if res.StopReason != SleepFailed {
+			t.Fatalf("expected to stop by %s, actual: %s", SleepFailed, res.StopReason)
+		}
+		if !errors.Is(err, errSleep) || !errors.Is(err, errTest) {
+			t.Fatalf("expected %v with %v, actual: %v", errSleep, errTest, err)
+		}
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected no context error, actual: %v", err)
+		}
+	})
+	t.Run("stops by the context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := New(Constant{
+			Context:     ctx,
+			Interval:    time.Hour,
+			MaxAttempts: 3,
+		}, WithSleep(func(ctx context.Context, d time.Duration) error {
+			cancel()
+			return ctx.Err()
+		}))
+		for r.Next() {
+		}
+		if r.reason != ContextCanceled {
+			t.Fatalf("expected to stop by %s, actual: %s", ContextCanceled, r.reason)
+		}
+	})
+}
@@ -0,0 +1,332 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Do calls fn until it succeeds or the retrier created from a gives up.
+// It returns nil if fn eventually succeeds, otherwise the last error returned by fn.
+// If the context of the algorithm is done while waiting, it returns promptly
+// with the last error wrapped by the error of the context, so that both
+// errors.Is(err, context.Canceled) and errors.Is(err, lastErr) hold.
+// The cause of the context, see context.Cause, is wrapped as well if any.
+func Do(a Algorithm, fn func() error, opts ...Option) error {
+	_, err := DoResult(a, fn, opts...)
+	return err
+}
+
+// DoContext behaves like Do but passes fn the context of the retrier,
+// so that the same context bounds both the calls and the waits between them.
+// It is Context of the algorithm, or the default timeout if it applies.
+// The context also carries a logger tagged with the attempt number, see Logger.
+func DoContext(a Algorithm, fn func(ctx context.Context) error, opts ...Option) error {
+	r := New(a, opts...)
+	_, _, err := run(r, func() (struct{}, error) {
+		ctx, cancel := r.attemptContext()
+		defer cancel()
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// DoIf behaves like Do but stops immediately and returns the error
+// if retryable reports that it is not worth retrying.
+func DoIf(a Algorithm, fn func() error, retryable func(err error) bool, opts ...Option) error {
+	return Do(a, fn, append(slices.Clip(opts), WithRetryIf(retryable))...)
+}
+
+// WithMaxAttemptsFunc makes the Do helpers decide whether to keep retrying
+// by calling keepGoing with the error and the number of attempts so far
+// after every failed attempt the Policy would retry, e.g. to retry network errors 10 times but 500s only 3 times.
+// It overrides MaxAttempts of the algorithm in the Do helpers.
+// A Next loop keeps stopping at MaxAttempts since only the Do helpers call keepGoing.
+func WithMaxAttemptsFunc(keepGoing func(err error, attempt int) bool) Option {
+	return func(r *retrier) {
+		r.keepGoing = keepGoing
+	}
+}
+
+// WithNotify makes the Do helpers call notify every time the function fails
+// and a retry is scheduled, with the error, the number of the failed attempt
+// and the interval to wait. It is called before waiting.
+// It is not called on the final failure, which the Do helpers return instead.
+// In a Next loop, it is called with the error recorded by SetErr.
+func WithNotify(notify func(err error, attempt int, next time.Duration)) Option {
+	return func(r *retrier) {
+		r.notify = notify
+	}
+}
+
+// WithOnFinish makes the Do helpers call finish with the telemetry of the loop once it ends,
+// e.g. to count give-ups or record the number of attempts until success.
+func WithOnFinish(finish func(res Result)) Option {
+	return func(r *retrier) {
+		r.finish = finish
+	}
+}
+
+// WithPerAttemptTimeout bounds every call of DoContext and DoWithResultContext by timeout
+// in addition to the context of the retrier, e.g. 2 seconds per attempt within 30 seconds in total.
+// The function receives a child context, so canceling the parent still cancels an attempt in flight.
+// An attempt that runs out of time fails with context.DeadlineExceeded and is retried like any other failure.
+func WithPerAttemptTimeout(timeout time.Duration) Option {
+	return func(r *retrier) {
+		r.attemptTimeout = timeout
+	}
+}
+
+// WithProbe makes the Do helpers call probe before the first attempt.
+// If probe fails, e.g. a cheap health check tells the dependency is down,
+// they return its error immediately without calling the function,
+// which avoids wasting the whole retry budget on a hopeless operation.
+func WithProbe(probe func() error) Option {
+	return func(r *retrier) {
+		r.probe = probe
+	}
+}
+
+// WithJoinedErrors makes the Do helpers return errors.Join of the errors of the failed attempts
+// in order instead of only the last one, so that the whole history of an intermittent failure
+// is visible and errors.Is matches any of them.
+// Only the last max errors are retained not to grow without bound in a long-running loop.
+// It has no effect if max is not positive.
+func WithJoinedErrors(max int) Option {
+	return func(r *retrier) {
+		r.maxJoined = max
+	}
+}
+
+// Result is the telemetry of a retry loop run by DoResult.
+type Result struct {
+	// Attempts is the number of times the function was called.
+	Attempts int
+	// Elapsed is the total duration of the retry loop.
+	Elapsed time.Duration
+	// SleptTotal is the total duration spent waiting between attempts.
+	SleptTotal time.Duration
+	// Succeeded reports whether the function eventually succeeded.
+	Succeeded bool
+	// StopReason is why the retry loop stopped.
+	StopReason StopReason
+	// Errors are the errors returned by failed attempts in order.
+	Errors []error
+}
+
+// DoResult behaves like Do and also returns the telemetry of the retry loop.
+func DoResult(a Algorithm, fn func() error, opts ...Option) (Result, error) {
+	r := New(a, opts...)
+	_, res, err := run(r, func() (struct{}, error) {
+		return struct{}{}, fn()
+	})
+	return res, err
+}
+
+// DoWithResult behaves like Do but carries the value returned by fn,
+// which is the value of the succeeded call or the last attempted one.
+func DoWithResult[T any](a Algorithm, fn func() (T, error), opts ...Option) (T, error) {
+	r := New(a, opts...)
+	v, _, err := run(r, fn)
+	return v, err
+}
+
+// DoWithResultContext behaves like DoWithResult but passes fn the context of the retrier
+// like DoContext does.
+func DoWithResultContext[T any](a Algorithm, fn func(ctx context.Context) (T, error), opts ...Option) (T, error) {
+	r := New(a, opts...)
+	v, _, err := run(r, func() (T, error) {
+		ctx, cancel := r.attemptContext()
+		defer cancel()
+		return fn(ctx)
+	})
+	return v, err
+}
+
+// DoFallback calls primary until it succeeds or the retrier created from a gives up,
+// e.g. calling a backup service or serving stale cache.
+// If primary never succeeds, it calls fallback once with the last error and returns its result.
+func DoFallback[T any](a Algorithm, primary func() (T, error), fallback func(lastErr error) (T, error), opts ...Option) (T, error) {
+	r := New(a, opts...)
+	v, _, err := run(r, primary)
+	if err != nil {
+		return fallback(err)
+	}
+	return v, nil
+}
+
+// DoAll calls every function of fns concurrently until it succeeds or its own retrier created from a gives up,
+// e.g. to fan out independent operations and wait for all of them.
+// It returns errors.Join of the errors Do returns for the functions in order, or nil if all succeed.
+//
+// ctx replaces Context of the algorithm for every retrier, so canceling it stops all of them early.
+func DoAll(ctx context.Context, a Algorithm, fns []func() error, opts ...Option) error {
+	opts = append(slices.Clip(opts), WithContext(ctx))
+	// Create the retriers up front since a jittered algorithm draws its seed from Rand,
+	// which is not safe for concurrent use.
+	rs := make([]*retrier, len(fns))
+	for i := range rs {
+		rs[i] = New(a, opts...)
+	}
+	errs := make([]error, len(fns))
+	var wg sync.WaitGroup
+	for i, fn := range fns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, errs[i] = run(rs[i], func() (struct{}, error) {
+				return struct{}{}, fn()
+			})
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// run calls fn until it succeeds or r gives up.
+func run[T any](r *retrier, fn func() (T, error)) (T, Result, error) {
+	var (
+		res     Result
+		v       T
+		err     error
+		history []error
+	)
+	r.byDo = true
+	start := r.timeNow()
+	if r.probe != nil {
+		if err = r.probe(); err != nil {
+			r.stop(ProbeFailed)
+			err = unwrapPermanent(err)
+			return v, r.result(res, start, err), err
+		}
+	}
+	for r.Next() {
+		r.allowed = false
+		res.Attempts++
+		called := r.timeNow()
+		v, err = call(r, fn)
+		r.Observe(r.since(called))
+		if r.breaker != nil {
+			r.breaker.Record(err == nil)
+		}
+		if err == nil {
+			r.reason = Success
+			break
+		}
+		var progressed bool
+		if err, progressed = unwrapProgress(err); progressed {
+			r.Progress()
+		}
+		res.Errors = append(res.Errors, err)
+		r.SetErr(err)
+		r.logError(err)
+		history = r.retainError(history, err)
+		decision := r.policy.Decide(err)
+		if decision == NoRetry {
+			r.stop(NotRetryable)
+			err = r.joinErrors(history, unwrapPermanent(err))
+			return v, r.result(res, start, err), err
+		}
+		if r.keepGoing != nil && !r.keepGoing(err, res.Attempts) {
+			r.stop(MaxAttempts)
+			break
+		}
+		if decision == RetryNow {
+			r.skipWait = true
+		}
+	}
+	if r.allowed {
+		// The loop stopped while waiting for the attempt the breaker allowed.
+		if b, ok := r.breaker.(releaser); ok {
+			b.release()
+		}
+	}
+	if r.reason == BreakerOpen && err == nil {
+		err = ErrBreakerOpen
+	}
+	err = r.wrapContextErr(r.joinErrors(history, err))
+	if r.reason == SleepFailed {
+		err = r.wrapSleepErr(err)
+	}
+	return v, r.result(res, start, err), err
+}
+
+// retainError appends err to history if WithJoinedErrors is given,
+// dropping the oldest error once it retains the maximum.
+func (r *retrier) retainError(history []error, err error) []error {
+	if r.maxJoined <= 0 {
+		return history
+	}
+	if len(history) < r.maxJoined {
+		return append(history, err)
+	}
+	copy(history, history[1:])
+	history[len(history)-1] = err
+	return history
+}
+
+// joinErrors joins history ending with last if WithJoinedErrors is given, otherwise returns last.
+// last replaces the latest error in history, e.g. after it is unwrapped from PermanentError.
+func (r *retrier) joinErrors(history []error, last error) error {
+	if last == nil || len(history) == 0 {
+		return last
+	}
+	history[len(history)-1] = last
+	return errors.Join(history...)
+}
+
+// wrapContextErr wraps err by the error of the context if the context stopped r,
+// and by its cause too if it was canceled with one, e.g. by context.WithCancelCause.
+// If err is nil, i.e. the context stopped r before the first attempt, it returns the error of the context.
+func (r *retrier) wrapContextErr(err error) error {
+	if r.reason != ContextCanceled && r.reason != Timeout {
+		return err
+	}
+	ctxErr := r.ctx.Err()
+	if ctxErr == nil || errors.Is(err, ctxErr) {
+		return err
+	}
+	cause := context.Cause(r.ctx)
+	if err == nil {
+		if cause != ctxErr {
+			return fmt.Errorf("retry: %w: %w", ctxErr, cause)
+		}
+		return ctxErr
+	}
+	if cause != ctxErr && !errors.Is(err, cause) {
+		return fmt.Errorf("retry: %w: %w: %w", ctxErr, cause, err)
+	}
+	return fmt.Errorf("retry: %w: %w", ctxErr, err)
+}
+
+// wrapSleepErr wraps err by the error of the sleep function which stopped r,
+// or returns the latter if no attempt failed.
+func (r *retrier) wrapSleepErr(err error) error {
+	if err == nil {
+		return r.sleepErr
+	}
+	return fmt.Errorf("retry: %w: %w", r.sleepErr, err)
+}
+
+// result completes res of a retry loop which started at start and ended with err.
+func (r *retrier) result(res Result, start time.Time, err error) Result {
+	r.flushError()
+	r.end()
+	res.Elapsed = r.since(start)
+	if r.latency != nil {
+		r.latency.Record(res.Elapsed)
+	}
+	if r.success != nil {
+		r.success.Record(err == nil)
+	}
+	res.SleptTotal = r.slept
+	res.Succeeded = err == nil
+	res.StopReason = r.reason
+	if r.finish != nil {
+		r.finish(res)
+	}
+	return res
+}
@@ -0,0 +1,826 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var errTest = errors.New("test")
+
+// failN returns a function that fails n times and then succeeds.
+func failN(n int, err error) func() error {
+	calls := 0
+	return func() error {
+		calls++
+		if calls <= n {
+			return err
+		}
+		return nil
+	}
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+	errTemporary := errors.New("temporary")
+	tests := []struct {
+		name        string
+		fn          func() error
+		expectedErr error
+	}{
+		{
+			name:        "succeed eventually",
+			fn:          failN(2, errTemporary),
+			expectedErr: nil,
+		},
+		{
+			name:        "exhaust attempts",
+			fn:          failN(5, errTemporary),
+			expectedErr: errTemporary,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Do(Constant{
+				Interval:    time.Millisecond,
+				MaxAttempts: 3,
+			}, tt.fn)
+			if err != tt.expectedErr {
+				t.Fatalf("expected %v, actual: %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestDoResult(t *testing.T) {
+	t.Parallel()
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+	tests := []struct {
+		name     string
+		fn       func() error
+		expected Result
+	}{
+		{
+			name: "succeed at 3rd attempt",
+			fn: func() func() error {
+				errs := []error{errFirst, errSecond, nil}
+				return func() error {
+					err := errs[0]
+					errs = errs[1:]
+					return err
+				}
+			}(),
+			expected: Result{
+				Attempts:   3,
+				Succeeded:  true,
+				StopReason: Success,
+				Errors:     []error{errFirst, errSecond},
+			},
+		},
+		{
+			name: "exhaust attempts",
+			fn:   failN(10, errFirst),
+			expected: Result{
+				Attempts:   4,
+				Succeeded:  false,
+				StopReason: MaxAttempts,
+				Errors:     []error{errFirst, errFirst, errFirst, errFirst},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interval := time.Millisecond
+			res, err := DoResult(Constant{
+				Interval:    interval,
+				MaxAttempts: 4,
+			}, tt.fn)
+			t.Logf("result: %+v", res)
+			if tt.expected.Succeeded != (err == nil) {
+				t.Fatalf("expected success to be %t, actual error: %v", tt.expected.Succeeded, err)
+			}
+			if res.Attempts != tt.expected.Attempts {
+				t.Fatalf("expected %d attempts, actual: %d", tt.expected.Attempts, res.Attempts)
+			}
+			if res.Succeeded != tt.expected.Succeeded {
+				t.Fatalf("expected Succeeded to be %t, actual: %t", tt.expected.Succeeded, res.Succeeded)
+			}
+			if res.StopReason != tt.expected.StopReason {
+				t.Fatalf("expected to stop by %s, actual: %s", tt.expected.StopReason, res.StopReason)
+			}
+			if !reflect.DeepEqual(res.Errors, tt.expected.Errors) {
+				t.Fatalf("expected errors %v, actual: %v", tt.expected.Errors, res.Errors)
+			}
+			leastSlept := time.Duration(res.Attempts-1) * interval
+			if res.SleptTotal < leastSlept {
+				t.Fatalf("expected to sleep %s at least, actual: %s", leastSlept, res.SleptTotal)
+			}
+			if res.Elapsed < res.SleptTotal {
+				t.Fatalf("expected elapsed %s not to be less than slept %s", res.Elapsed, res.SleptTotal)
+			}
+		})
+	}
+}
+
+func TestWithMaxAttemptsFunc(t *testing.T) {
+	t.Parallel()
+	errNetwork := errors.New("network")
+	errServer := errors.New("500")
+	keepGoing := func(err error, attempt int) bool {
+		if errors.Is(err, errNetwork) {
+			return attempt < 10
+		}
+		return attempt < 3
+	}
+	tests := []struct {
+		name          string
+		errs          []error
+		exactAttempts int
+	}{
+		{
+			name:          "network errors get the full budget",
+			errs:          []error{errNetwork},
+			exactAttempts: 10,
+		},
+		{
+			name:          "server errors get fewer attempts",
+			errs:          []error{errServer},
+			exactAttempts: 3,
+		},
+		{
+			name:          "server error after network errors",
+			errs:          []error{errNetwork, errNetwork, errNetwork, errServer},
+			exactAttempts: 4,
+		},
+		{
+			name:          "network error after server errors",
+			errs:          []error{errServer, errServer, errNetwork},
+			exactAttempts: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := Do(Constant{
+				Interval:    time.Microsecond,
+				MaxAttempts: 2,
+			}, func() error {
+				err := tt.errs[len(tt.errs)-1]
+				if attempts < len(tt.errs) {
+					err = tt.errs[attempts]
+				}
+				attempts++
+				return err
+			}, WithMaxAttemptsFunc(keepGoing))
+			if err == nil {
+				t.Fatal("expected to fail")
+			}
+			if attempts != tt.exactAttempts {
+				t.Fatalf("expected to reach %d attempts, actual: %d", tt.exactAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestWithMaxAttemptsFunc_permanent(t *testing.T) {
+	t.Parallel()
+	called := false
+	res, err := DoResult(Constant{
+		Interval:    time.Microsecond,
+		MaxAttempts: 3,
+	}, func() error {
+		return Permanent(errTest)
+	}, WithMaxAttemptsFunc(func(err error, attempt int) bool {
+		called = true
+		return false
+	}))
+	if called {
+		t.Fatal("expected keepGoing not to be called for an error the policy does not retry")
+	}
+	if err != errTest {
+		t.Fatalf("expected %v unwrapped, actual: %#v", errTest, err)
+	}
+	if res.StopReason != NotRetryable {
+		t.Fatalf("expected to stop by %s, actual: %s", NotRetryable, res.StopReason)
+	}
+}
+
+func TestWithMaxAttemptsFunc_next(t *testing.T) {
+	t.Parallel()
+	r := New(Constant{
+		Interval:    time.Microsecond,
+		MaxAttempts: 3,
+	}, WithMaxAttemptsFunc(func(err error, attempt int) bool {
+		return true
+	}))
+	attempts := 0
+	for r.Next() {
+		attempts++
+		if attempts > 3 {
+			t.Fatal("expected a Next loop to stop at MaxAttempts")
+		}
+	}
+	if r.StopReason() != MaxAttempts {
+		t.Fatalf("expected to stop by %s, actual: %s", MaxAttempts, r.StopReason())
+	}
+}
+
+func TestWithProbe(t *testing.T) {
+	t.Parallel()
+	errDown := errors.New("down")
+	tests := []struct {
+		name          string
+		probe         func() error
+		exactAttempts int
+		expectedErr   error
+	}{
+		{
+			name:          "healthy",
+			probe:         func() error { return nil },
+			exactAttempts: 3,
+			expectedErr:   errTest,
+		},
+		{
+			name:          "down",
+			probe:         func() error { return errDown },
+			exactAttempts: 0,
+			expectedErr:   errDown,
+		},
+		{
+			name:          "permanently down",
+			probe:         func() error { return Permanent(errDown) },
+			exactAttempts: 0,
+			expectedErr:   errDown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			res, err := DoResult(Constant{
+				Interval:    time.Millisecond,
+				MaxAttempts: 3,
+			}, func() error {
+				attempts++
+				return errTest
+			}, WithProbe(tt.probe))
+			if err != tt.expectedErr {
+				t.Fatalf("expected %v, actual: %#v", tt.expectedErr, err)
+			}
+			if attempts != tt.exactAttempts {
+				t.Fatalf("expected to reach %d attempts, actual: %d", tt.exactAttempts, attempts)
+			}
+			if res.Attempts != tt.exactAttempts {
+				t.Fatalf("expected to report %d attempts, actual: %d", tt.exactAttempts, res.Attempts)
+			}
+		})
+	}
+}
+
+func TestDoWithResult(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		failures    int
+		expected    int
+		expectedErr error
+	}{
+		{
+			name:     "succeeds eventually",
+			failures: 2,
+			expected: 3,
+		},
+		{
+			name:        "exhausts attempts",
+			failures:    3,
+			expected:    -3,
+			expectedErr: errTest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			v, err := DoWithResult(Constant{
+				Interval:    time.Millisecond,
+				MaxAttempts: 3,
+			}, func() (int, error) {
+				attempts++
+				if attempts <= tt.failures {
+					return -attempts, errTest
+				}
+				return attempts, nil
+			})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, actual: %v", tt.expectedErr, err)
+			}
+			if v != tt.expected {
+				t.Fatalf("expected %d, actual: %d", tt.expected, v)
+			}
+		})
+	}
+}
+
+func TestDoFallback(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name              string
+		failures          int
+		expected          string
+		expectedFallbacks int
+	}{
+		{
+			name:              "primary succeeds eventually",
+			failures:          2,
+			expected:          "primary",
+			expectedFallbacks: 0,
+		},
+		{
+			name:              "primary exhausts attempts",
+			failures:          3,
+			expected:          "fallback",
+			expectedFallbacks: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts, fallbacks := 0, 0
+			v, err := DoFallback(Constant{
+				Interval:    time.Millisecond,
+				MaxAttempts: 3,
+			}, func() (string, error) {
+				attempts++
+				if attempts <= tt.failures {
+					return "", errTest
+				}
+				return "primary", nil
+			}, func(lastErr error) (string, error) {
+				fallbacks++
+				if !errors.Is(lastErr, errTest) {
+					t.Fatalf("expected the fallback to receive %v, actual: %v", errTest, lastErr)
+				}
+				return "fallback", nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v != tt.expected {
+				t.Fatalf("expected %q, actual: %q", tt.expected, v)
+			}
+			if fallbacks != tt.expectedFallbacks {
+				t.Fatalf("expected to fall back %d times, actual: %d", tt.expectedFallbacks, fallbacks)
+			}
+		})
+	}
+}
+
+func TestDoAll(t *testing.T) {
+	t.Parallel()
+	errOther := errors.New("other")
+	a := Constant{Interval: time.Millisecond, MaxAttempts: 3}
+	t.Run("succeeds", func(t *testing.T) {
+		err := DoAll(context.Background(), a, []func() error{failN(2, errTest), failN(1, errOther), failN(0, errTest)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("joins errors", func(t *testing.T) {
+		err := DoAll(context.Background(), a, []func() error{failN(3, errTest), failN(2, errTest), failN(3, errOther)})
+		if !errors.Is(err, errTest) || !errors.Is(err, errOther) {
+			t.Fatalf("expected %v and %v joined, actual: %v", errTest, errOther, err)
+		}
+	})
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		start := time.Now()
+		fail := func() error { return errTest }
+		err := DoAll(ctx, Constant{Interval: time.Hour}, []func() error{fail, fail})
+		if elapsed := time.Since(start); time.Second < elapsed {
+			t.Fatalf("expected to return promptly, actual: %s", elapsed)
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected %v, actual: %v", context.Canceled, err)
+		}
+	})
+	t.Run("shares Rand", func(t *testing.T) {
+		// Run with -race to detect concurrent draws from Rand.
+		a := Jitter{Base: time.Microsecond, Max: time.Microsecond, MaxAttempts: 2, Rand: rand.New(rand.NewSource(1))}
+		fns := make([]func() error, 8)
+		for i := range fns {
+			fns[i] = failN(1, errTest)
+		}
+		if err := DoAll(context.Background(), a, fns); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestDoContext(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	err := DoContext(Constant{Context: ctx, Interval: time.Microsecond}, func(ctx context.Context) error {
+		attempts++
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, actual: %v", context.Canceled, err)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected the canceled context to stop retrying after 1 attempt, actual: %d", attempts)
+	}
+}
+
+func TestDo_canceledWhileWaiting(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	err := Do(Constant{Context: ctx, Interval: time.Hour}, func() error {
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return errTest
+	})
+	if elapsed := time.Since(start); time.Second < elapsed {
+		t.Fatalf("expected to return promptly, actual: %s", elapsed)
+	}
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, errTest) {
+		t.Fatalf("expected %v wrapped with %v, actual: %v", errTest, context.Canceled, err)
+	}
+}
+
+func TestDo_canceledBeforeFirstAttempt(t *testing.T) {
+	t.Parallel()
+	errShutdown := errors.New("shutting down")
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	caused, cancelCause := context.WithCancelCause(context.Background())
+	cancelCause(errShutdown)
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		expectedErrs   []error
+		expectedReason StopReason
+	}{
+		{
+			name:           "canceled",
+			ctx:            canceled,
+			expectedErrs:   []error{context.Canceled},
+			expectedReason: ContextCanceled,
+		},
+		{
+			name:           "canceled with a cause",
+			ctx:            caused,
+			expectedErrs:   []error{context.Canceled, errShutdown},
+			expectedReason: ContextCanceled,
+		},
+		{
+			name:           "timeout",
+			ctx:            timeoutCtx(10 * time.Millisecond),
+			expectedErrs:   []error{context.DeadlineExceeded},
+			expectedReason: Timeout,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			calls := 0
+			res, err := DoResult(Constant{Context: tt.ctx, InitialDelay: time.Hour}, func() error {
+				calls++
+				return nil
+			})
+			if calls != 0 {
+				t.Fatalf("expected no call, actual: %d", calls)
+			}
+			for _, target := range tt.expectedErrs {
+				if !errors.Is(err, target) {
+					t.Fatalf("expected %v to wrap %v", err, target)
+				}
+			}
+			if res.StopReason != tt.expectedReason {
+				t.Fatalf("expected to stop by %s, actual: %s", tt.expectedReason, res.StopReason)
+			}
+		})
+	}
+}
+
+func TestDoContext_cancelCause(t *testing.T) {
+	t.Parallel()
+	errShutdown := errors.New("shutting down")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	err := DoContext(Constant{Context: ctx, Interval: time.Hour}, func(ctx context.Context) error {
+		time.AfterFunc(10*time.Millisecond, func() { cancel(errShutdown) })
+		return errTest
+	})
+	for _, target := range []error{context.Canceled, errShutdown, errTest} {
+		if !errors.Is(err, target) {
+			t.Fatalf("expected %v to wrap %v", err, target)
+		}
+	}
+	if expected := "retry: context canceled: shutting down: test"; err.Error() != expected {
+		t.Fatalf("expected %q, actual: %q", expected, err.Error())
+	}
+}
+
+func TestDoIf(t *testing.T) {
+	t.Parallel()
+	errBadRequest := errors.New("bad request")
+	tests := []struct {
+		name             string
+		err              error
+		expectedAttempts int
+	}{
+		{
+			name:             "retryable",
+			err:              errTest,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "not retryable",
+			err:              fmt.Errorf("wrapped: %w", errBadRequest),
+			expectedAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			err := DoIf(Constant{
+				Interval:    time.Millisecond,
+				MaxAttempts: 3,
+			}, func() error {
+				attempts++
+				return tt.err
+			}, func(err error) bool {
+				return !errors.Is(err, errBadRequest)
+			})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, actual: %v", tt.err, err)
+			}
+			if attempts != tt.expectedAttempts {
+				t.Fatalf("expected %d attempts, actual: %d", tt.expectedAttempts, attempts)
+			}
+		})
+	}
+}
+
+func TestDoIf_sharedOptions(t *testing.T) {
+	t.Parallel()
+	a := Constant{Interval: time.Millisecond, MaxAttempts: 2}
+	opts := make([]Option, 0, 1)
+	notified := 0
+	withNotify := append(opts, WithNotify(func(err error, attempt int, next time.Duration) {
+		notified++
+	}))
+	_ = DoIf(a, failN(1, errTest), func(err error) bool { return false }, opts...)
+	_ = Do(a, failN(1, errTest), withNotify...)
+	if notified != 1 {
+		t.Fatalf("expected DoIf not to overwrite options sharing the array of opts, actual: %d notifications", notified)
+	}
+}
+
+func TestDoWithResultContext(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	attempts := 0
+	v, err := DoWithResultContext(Constant{Context: ctx, Interval: time.Millisecond}, func(ctx context.Context) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errTest
+		}
+		// A hung call is aborted by the same context which bounds the waits.
+		<-ctx.Done()
+		return attempts, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, actual: %v", context.DeadlineExceeded, err)
+	}
+	if v != 3 {
+		t.Fatalf("expected the value of the last attempt, actual: %d", v)
+	}
+}
+
+func TestWithNotify(t *testing.T) {
+	t.Parallel()
+	interval := time.Millisecond
+	type notification struct {
+		err     error
+		attempt int
+		next    time.Duration
+	}
+	var notified []notification
+	errs := []error{errors.New("1"), errors.New("2"), errors.New("3")}
+	calls := 0
+	err := Do(Constant{
+		Interval:    interval,
+		MaxAttempts: 3,
+	}, func() error {
+		err := errs[calls]
+		calls++
+		return err
+	}, WithNotify(func(err error, attempt int, next time.Duration) {
+		notified = append(notified, notification{err: err, attempt: attempt, next: next})
+	}))
+	if err != errs[2] {
+		t.Fatalf("expected the final failure %v to be returned, actual: %v", errs[2], err)
+	}
+	expected := []notification{
+		{err: errs[0], attempt: 1, next: interval},
+		{err: errs[1], attempt: 2, next: interval},
+	}
+	if !reflect.DeepEqual(notified, expected) {
+		t.Fatalf("expected %v, actual: %v", expected, notified)
+	}
+}
+
+func TestWithJoinedErrors(t *testing.T) {
+	t.Parallel()
+	errs := []error{errors.New("1"), errors.New("2"), errors.New("3"), errors.New("4")}
+	tests := []struct {
+		name      string
+		max       int
+		fail      func(attempt int) error
+		expected  string
+		matched   []error
+		unmatched []error
+	}{
+		{
+			name:     "joins every error",
+			max:      10,
+			fail:     func(attempt int) error { return errs[attempt] },
+			expected: "1\n2\n3\n4",
+			matched:  errs,
+		},
+		{
+			name:      "retains the last errors",
+			max:       2,
+			fail:      func(attempt int) error { return errs[attempt] },
+			expected:  "3\n4",
+			matched:   errs[2:],
+			unmatched: errs[:2],
+		},
+		{
+			name: "unwraps a permanent error",
+			max:  10,
+			fail: func(attempt int) error {
+				if attempt == 1 {
+					return Permanent(errs[1])
+				}
+				return errs[attempt]
+			},
+			expected:  "1\n2",
+			matched:   errs[:2],
+			unmatched: errs[2:],
+		},
+		{
+			name:     "disabled",
+			fail:     func(attempt int) error { return errs[attempt] },
+			expected: "4",
+			matched:  errs[3:],
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			attempt := 0
+			err := Do(Constant{MaxAttempts: 4}, func() error {
+				err := tt.fail(attempt)
+				attempt++
+				return err
+			}, WithClock(&fakeClock{}), WithJoinedErrors(tt.max))
+			if err == nil || err.Error() != tt.expected {
+				t.Fatalf("expected %q, actual: %v", tt.expected, err)
+			}
+			for _, e := range tt.matched {
+				if !errors.Is(err, e) {
+					t.Fatalf("expected %v to match %v", err, e)
+				}
+			}
+			for _, e := range tt.unmatched {
+				if errors.Is(err, e) {
+					t.Fatalf("expected %v not to match %v", err, e)
+				}
+			}
+		})
+	}
+}
+
+func TestWithJoinedErrors_success(t *testing.T) {
+	t.Parallel()
+	if err := Do(Constant{MaxAttempts: 3}, failN(2, errTest), WithClock(&fakeClock{}), WithJoinedErrors(10)); err != nil {
+		t.Fatalf("expected no error once the function succeeds, actual: %v", err)
+	}
+}
+
+func TestWithOnFinish(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		fn       func() error
+		expected Result
+	}{
+		{
+			name:     "success",
+			fn:       failN(1, errTest),
+			expected: Result{Attempts: 2, Succeeded: true, StopReason: Success},
+		},
+		{
+			name:     "give up",
+			fn:       failN(3, errTest),
+			expected: Result{Attempts: 3, StopReason: MaxAttempts},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var finished []Result
+			res, _ := DoResult(Constant{MaxAttempts: 3}, tt.fn, WithClock(&fakeClock{}), WithOnFinish(func(res Result) {
+				finished = append(finished, res)
+			}))
+			if len(finished) != 1 {
+				t.Fatalf("expected to be called once, actual: %d", len(finished))
+			}
+			if !reflect.DeepEqual(finished[0], res) {
+				t.Fatalf("expected %+v, actual: %+v", res, finished[0])
+			}
+			if res.Attempts != tt.expected.Attempts || res.Succeeded != tt.expected.Succeeded || res.StopReason != tt.expected.StopReason {
+				t.Fatalf("expected %+v, actual: %+v", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestWithPerAttemptTimeout(t *testing.T) {
+	t.Parallel()
+	attempts := 0
+	err := DoContext(Constant{MaxAttempts: 3}, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			// Hangs until the attempt runs out of time.
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}, WithClock(&fakeClock{}), WithPerAttemptTimeout(5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("expected timed out attempts to be retried, actual: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, actual: %d", attempts)
+	}
+}
+
+func TestWithPerAttemptTimeout_parentCanceled(t *testing.T) {
+	t.Parallel()
+	parent, cancel := context.WithCancel(context.Background())
+	err := DoContext(Constant{Context: parent}, func(ctx context.Context) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithPerAttemptTimeout(time.Hour))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the attempt to be canceled by the parent, actual: %v", err)
+	}
+}
+
+func TestDoContext_deadline(t *testing.T) {
+	t.Parallel()
+	deadline := time.Now().Add(time.Hour)
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected bool
+	}{
+		{
+			name:     "bounds the context without Context",
+			expected: true,
+		},
+		{
+			name: "passes Context as is",
+			ctx:  context.Background(),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			err := DoContext(Constant{Context: tt.ctx, Deadline: deadline}, func(ctx context.Context) error {
+				d, ok := ctx.Deadline()
+				if ok != tt.expected || (ok && !d.Equal(deadline)) {
+					t.Fatalf("expected the deadline %v to be %v, actual: %v, %v", deadline, tt.expected, d, ok)
+				}
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package retry
+
+import "time"
+
+// eventBuffer is the number of events buffered for a slow consumer.
+const eventBuffer = 64
+
+// Event describes an attempt performed by a retrier.
+type Event struct {
+	// Attempt is the number of the attempt starting at 1.
+	Attempt int
+	// Interval is the duration waited before the attempt. It is InitialDelay for the first attempt.
+	Interval time.Duration
+	// At is the time of the attempt.
+	At time.Time
+}
+
+// Events returns a channel streaming an Event for every attempt in order,
+// which is closed when the loop ends: when Next returns false, when a range over Iter stops
+// for any reason including success, or when Reset is called.
+// A loop breaking out of Next by itself, e.g. on success, should call Reset to close it.
+// It never blocks the retry loop: the channel buffers 64 events
+// and further events are dropped while the buffer is full.
+// Call it before the retry loop to receive every event.
+func (r *retrier) Events() <-chan Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.events == nil {
+		r.events = make(chan Event, eventBuffer)
+		if r.eventsClosed {
+			close(r.events)
+		}
+	}
+	return r.events
+}
+
+// WithOnRetry calls onRetry just before the retrier waits for the next retry
+// with the number of the attempt about to be performed, starting at 2, and the interval to wait,
+// e.g. to log or count retries without touching the loop body.
+// It is not called after the final attempt since no wait happens.
+func WithOnRetry(onRetry func(attempt int, next time.Duration)) Option {
+	return func(r *retrier) {
+		r.onRetry = onRetry
+	}
+}
+
+// AttemptInfo describes an attempt about to be performed after a wait.
+type AttemptInfo struct {
+	// Number is the number of the attempt starting at 1, so it is 2 or more.
+	Number int
+	// Delay is the duration to wait before the attempt.
+	Delay time.Duration
+	// At is the time the wait starts.
+	At time.Time
+}
+
+// WithAttemptInfo sends an AttemptInfo on ch just before the retrier waits for the next retry,
+// e.g. to visualize retries live. It is a push-based alternative to WithOnRetry.
+// It never blocks the retry loop: the info is dropped if ch is not ready to receive.
+// The caller owns ch, so the retrier never closes it.
+func WithAttemptInfo(ch chan<- AttemptInfo) Option {
+	return func(r *retrier) {
+		r.attemptInfo = ch
+	}
+}
+
+// publish sends an AttemptInfo of the attempt about to be performed without blocking.
+func (r *retrier) publish(d time.Duration) {
+	if r.attemptInfo == nil {
+		return
+	}
+	select {
+	case r.attemptInfo <- AttemptInfo{Number: r.attempts + 1, Delay: d, At: r.timeNow()}:
+	default:
+	}
+}
+
+// emit sends an Event of the attempt being performed without blocking.
+func (r *retrier) emit() {
+	if r.events == nil || r.eventsClosed {
+		return
+	}
+	e := Event{
+		Attempt: r.attempts + 1,
+		At:      r.timeNow(),
+	}
+	if r.attempts != 0 || r.initialDelay > 0 {
+		e.Interval = r.lastInterval
+	}
+	select {
+	case r.events <- e:
+	default:
+	}
+}
+
+// closeEvents closes the channel of events when the loop ends.
+// It is safe to call more than once.
+func (r *retrier) closeEvents() {
+	if r.eventsClosed {
+		return
+	}
+	r.eventsClosed = true
+	if r.events != nil {
+		close(r.events)
+	}
+}
+
+// end closes the channel of events when a loop driven by the retrier itself ends.
+func (r *retrier) end() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closeEvents()
+}
@@ -0,0 +1,179 @@
+package retry
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRetrier_Events(t *testing.T) {
+	t.Parallel()
+	interval := time.Millisecond
+	r := New(Constant{
+		Interval:    interval,
+		MaxAttempts: 5,
+	})
+	events := r.Events()
+	done := make(chan struct{})
+	var received []Event
+	go func() {
+		defer close(done)
+		for e := range events {
+			received = append(received, e)
+		}
+	}()
+	for r.Next() {
+	}
+	<-done
+	if len(received) != 5 {
+		t.Fatalf("expected 5 events, actual: %d", len(received))
+	}
+	for i, e := range received {
+		if e.Attempt != i+1 {
+			t.Fatalf("expected attempt %d, actual: %d", i+1, e.Attempt)
+		}
+		if i == 0 {
+			if e.Interval != 0 {
+				t.Fatalf("expected no interval before the first attempt, actual: %s", e.Interval)
+			}
+			continue
+		}
+		if e.Interval != interval {
+			t.Fatalf("expected interval %s before attempt %d, actual: %s", interval, e.Attempt, e.Interval)
+		}
+		if e.At.Before(received[i-1].At) {
+			t.Fatalf("expected attempt %d not to be before the previous one", e.Attempt)
+		}
+	}
+}
+
+func TestRetrier_Events_slowConsumer(t *testing.T) {
+	t.Parallel()
+	r := New(Constant{
+		Interval:    time.Microsecond,
+		MaxAttempts: 2 * eventBuffer,
+	})
+	events := r.Events()
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		for r.Next() {
+		}
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a consumer not reading events not to stall the retry loop")
+	}
+	n := 0
+	for e := range events {
+		n++
+		if e.Attempt != n {
+			t.Fatalf("expected buffered events in order, actual: attempt %d at %d", e.Attempt, n)
+		}
+	}
+	if n != eventBuffer {
+		t.Fatalf("expected to buffer %d events and drop the rest, actual: %d", eventBuffer, n)
+	}
+}
+
+func TestRetrier_Events_closed(t *testing.T) {
+	t.Parallel()
+	closed := func(events <-chan Event) bool {
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case _, ok := <-events:
+				if !ok {
+					return true
+				}
+			case <-timeout:
+				return false
+			}
+		}
+	}
+	t.Run("success in Iter", func(t *testing.T) {
+		r := New(Constant{
+			Interval:    time.Microsecond,
+			MaxAttempts: 5,
+		})
+		events := r.Events()
+		for attempt := range r.Iter() {
+			if attempt == 2 {
+				break
+			}
+		}
+		if !closed(events) {
+			t.Fatal("expected events to be closed when the loop succeeds")
+		}
+	})
+	t.Run("Reset", func(t *testing.T) {
+		r := New(Constant{
+			Interval:    time.Microsecond,
+			MaxAttempts: 5,
+		})
+		events := r.Events()
+		if !r.Next() {
+			t.Fatal("expected the first attempt")
+		}
+		r.Reset()
+		if !closed(events) {
+			t.Fatal("expected events to be closed by Reset")
+		}
+		events = r.Events()
+		for r.Next() {
+		}
+		n := 0
+		for range events {
+			n++
+		}
+		if n != 5 {
+			t.Fatalf("expected 5 events after Reset, actual: %d", n)
+		}
+	})
+}
+
+func TestWithOnRetry(t *testing.T) {
+	t.Parallel()
+	interval := time.Millisecond
+	var attempts []int
+	r := New(Constant{
+		Interval:    interval,
+		MaxAttempts: 3,
+	}, WithOnRetry(func(attempt int, next time.Duration) {
+		if next != interval {
+			t.Errorf("expected to wait %s before attempt %d, actual: %s", interval, attempt, next)
+		}
+		attempts = append(attempts, attempt)
+	}))
+	for r.Next() {
+	}
+	expected := []int{2, 3}
+	if !reflect.DeepEqual(attempts, expected) {
+		t.Fatalf("expected to be called before attempts %v, actual: %v", expected, attempts)
+	}
+}
+
+func TestWithAttemptInfo(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{}
+	ch := make(chan AttemptInfo, 2)
+	r := New(Constant{
+		Interval:    time.Second,
+		MaxAttempts: 4,
+	}, WithClock(clock), WithAttemptInfo(ch))
+	for r.Next() {
+	}
+	// The buffer holds 2 infos, so the info of the 4th attempt is dropped instead of blocking.
+	expected := []AttemptInfo{
+		{Number: 2, Delay: time.Second, At: time.Time{}},
+		{Number: 3, Delay: time.Second, At: time.Time{}.Add(time.Second)},
+	}
+	var actual []AttemptInfo
+	for len(ch) > 0 {
+		actual = append(actual, <-ch)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v, actual: %v", expected, actual)
+	}
+}
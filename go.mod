@@ -1,3 +1,3 @@
 module github.com/keisku/retry
 
-go 1.17
+go 1.23
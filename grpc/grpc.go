@@ -0,0 +1,78 @@
+// Package grpc retries gRPC calls by an interceptor.
+// It lives apart from package retry so that only its users depend on gRPC.
+package grpc
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/keisku/retry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// pushbackKey is the trailer by which a server tells how long to wait before retrying.
+const pushbackKey = "grpc-retry-pushback-ms"
+
+// defaultMaxAttempts bounds the retries of a call without a deadline unless the algorithm bounds them.
+const defaultMaxAttempts = 3
+
+// UnaryClientInterceptor returns an interceptor which retries calls failing with any of retryable codes
+// by a retrier created from a and opts. If retryable is empty, it retries codes.Unavailable only.
+// Other codes are returned immediately.
+//
+// The loop is bounded by the context of the call, which replaces Context of the algorithm.
+// A call without a deadline is attempted 3 times at most unless the algorithm or opts bound the loop,
+// e.g. by MaxAttempts, or retry.WithoutTimeout is given to retry until the call is canceled.
+// A server can push back by the grpc-retry-pushback-ms trailer: the next retry waits for it
+// instead of the interval of the algorithm, and a negative or malformed value stops retrying.
+func UnaryClientInterceptor(a retry.Algorithm, retryable []codes.Code, opts ...retry.Option) grpc.UnaryClientInterceptor {
+	if len(retryable) == 0 {
+		retryable = []codes.Code{codes.Unavailable}
+	}
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		ropts := append(slices.Clip(opts), retry.WithContext(ctx))
+		if _, ok := ctx.Deadline(); !ok {
+			ropts = append(ropts, retry.WithDefaultMaxAttempts(defaultMaxAttempts))
+		}
+		r := retry.New(a, ropts...)
+		var err error
+		for r.Next() {
+			var trailer metadata.MD
+			err = invoker(ctx, method, req, reply, cc, append(slices.Clip(callOpts), grpc.Trailer(&trailer))...)
+			if err == nil || !slices.Contains(retryable, status.Code(err)) {
+				return err
+			}
+			if d, ok := pushback(trailer); ok {
+				if d < 0 {
+					return err
+				}
+				r.SetNextDelay(d)
+			}
+			r.SetErr(err)
+		}
+		if err == nil {
+			// The loop stopped before the first attempt, e.g. the context is done during InitialDelay.
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return err
+	}
+}
+
+// pushback returns the duration the server asked to wait if any.
+// It returns a negative duration if the server asked not to retry.
+func pushback(trailer metadata.MD) (time.Duration, bool) {
+	vs := trailer.Get(pushbackKey)
+	if len(vs) == 0 {
+		return 0, false
+	}
+	ms, err := strconv.ParseInt(vs[0], 10, 64)
+	if err != nil {
+		return -1, true
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
@@ -0,0 +1,157 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/keisku/retry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// response is what a fake server responds to a call.
+type response struct {
+	code     codes.Code
+	pushback string
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		interval      time.Duration
+		retryable     []codes.Code
+		responses     []response
+		expectedCode  codes.Code
+		expectedCalls int
+	}{
+		{
+			name:          "succeeds after retries",
+			interval:      time.Millisecond,
+			responses:     []response{{code: codes.Unavailable}, {code: codes.Unavailable}, {code: codes.OK}},
+			expectedCode:  codes.OK,
+			expectedCalls: 3,
+		},
+		{
+			name:          "never succeeds",
+			interval:      time.Millisecond,
+			responses:     []response{{code: codes.Unavailable}, {code: codes.Unavailable}, {code: codes.Unavailable}},
+			expectedCode:  codes.Unavailable,
+			expectedCalls: 3,
+		},
+		{
+			name:          "not retryable",
+			interval:      time.Millisecond,
+			responses:     []response{{code: codes.InvalidArgument}},
+			expectedCode:  codes.InvalidArgument,
+			expectedCalls: 1,
+		},
+		{
+			name:          "retryable codes",
+			interval:      time.Millisecond,
+			retryable:     []codes.Code{codes.ResourceExhausted},
+			responses:     []response{{code: codes.ResourceExhausted}, {code: codes.Unavailable}},
+			expectedCode:  codes.Unavailable,
+			expectedCalls: 2,
+		},
+		{
+			name:          "pushback",
+			interval:      time.Hour,
+			responses:     []response{{code: codes.Unavailable, pushback: "1"}, {code: codes.OK}},
+			expectedCode:  codes.OK,
+			expectedCalls: 2,
+		},
+		{
+			name:          "negative pushback",
+			interval:      time.Millisecond,
+			responses:     []response{{code: codes.Unavailable, pushback: "-1"}},
+			expectedCode:  codes.Unavailable,
+			expectedCalls: 1,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			interceptor := UnaryClientInterceptor(retry.Constant{
+				Interval:    tt.interval,
+				MaxAttempts: 3,
+			}, tt.retryable)
+			calls := 0
+			invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				res := tt.responses[calls]
+				calls++
+				if res.pushback != "" {
+					for _, opt := range opts {
+						if trailer, ok := opt.(grpc.TrailerCallOption); ok {
+							*trailer.TrailerAddr = metadata.Pairs(pushbackKey, res.pushback)
+						}
+					}
+				}
+				return status.Error(res.code, res.code.String())
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			err := interceptor(ctx, "/test.Service/Method", nil, nil, nil, invoker)
+			if code := status.Code(err); code != tt.expectedCode {
+				t.Fatalf("expected %s, actual: %v", tt.expectedCode, err)
+			}
+			if calls != tt.expectedCalls {
+				t.Fatalf("expected %d calls, actual: %d", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestUnaryClientInterceptor_noDeadline(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		algorithm     retry.Algorithm
+		expectedCalls int
+	}{
+		{name: "default", algorithm: retry.Constant{Interval: time.Millisecond}, expectedCalls: defaultMaxAttempts},
+		{name: "MaxAttempts", algorithm: retry.Constant{Interval: time.Millisecond, MaxAttempts: 5}, expectedCalls: 5},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			interceptor := UnaryClientInterceptor(tt.algorithm, nil)
+			calls := 0
+			invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				calls++
+				return status.Error(codes.Unavailable, codes.Unavailable.String())
+			}
+			err := interceptor(context.Background(), "/test.Service/Method", nil, nil, nil, invoker)
+			if code := status.Code(err); code != codes.Unavailable {
+				t.Fatalf("expected %s, actual: %v", codes.Unavailable, err)
+			}
+			if calls != tt.expectedCalls {
+				t.Fatalf("expected %d calls, actual: %d", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestUnaryClientInterceptor_canceledBeforeFirstAttempt(t *testing.T) {
+	t.Parallel()
+	interceptor := UnaryClientInterceptor(retry.Constant{InitialDelay: time.Hour, MaxAttempts: 3}, nil)
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := interceptor(ctx, "/test.Service/Method", nil, nil, nil, invoker)
+	if code := status.Code(err); code != codes.Canceled {
+		t.Fatalf("expected %s, actual: %v", codes.Canceled, err)
+	}
+	if calls != 0 {
+		t.Fatalf("expected no call, actual: %d", calls)
+	}
+}
@@ -0,0 +1,40 @@
+package retry
+
+// Guard decides whether a retry may be performed.
+// It is consulted before every retry, but not before the first attempt.
+// When it denies, the retry loop stops immediately.
+//
+// AllowRetry is called without locking the retrier, so it may call its methods, e.g. Attempts.
+//
+// A Guard is typically shared among many retriers, e.g. to suppress retries
+// once an error budget is exhausted, so implementations should be safe for concurrent use.
+type Guard interface {
+	AllowRetry() bool
+}
+
+// AlwaysAllow is a Guard that allows every retry. It is used by default.
+var AlwaysAllow Guard = alwaysAllow{}
+
+type alwaysAllow struct{}
+
+func (alwaysAllow) AllowRetry() bool { return true }
+
+// depositor is implemented by guards that are told about every first attempt,
+// e.g. a Budget earning retries from calls.
+type depositor interface {
+	deposit()
+}
+
+// deposit tells the guard about a first attempt if it is a depositor.
+func (r *retrier) deposit() {
+	if d, ok := r.guard.(depositor); ok {
+		d.deposit()
+	}
+}
+
+// WithGuard consults g before every retry.
+func WithGuard(g Guard) Option {
+	return func(r *retrier) {
+		r.guard = g
+	}
+}
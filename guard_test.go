@@ -0,0 +1,89 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+type countdownGuard int
+
+func (g *countdownGuard) AllowRetry() bool {
+	if *g == 0 {
+		return false
+	}
+	*g--
+	return true
+}
+
+func TestWithGuard(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		guard         Guard
+		exactAttempts int
+	}{
+		{
+			name:          "always allow",
+			guard:         AlwaysAllow,
+			exactAttempts: 5,
+		},
+		{
+			name: "deny after 2 retries",
+			guard: func() Guard {
+				g := countdownGuard(2)
+				return &g
+			}(),
+			exactAttempts: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(Constant{
+				Interval:    time.Millisecond,
+				MaxAttempts: 5,
+			}, WithGuard(tt.guard))
+			attempts := 0
+			for r.Next() {
+				attempts++
+			}
+			if attempts != tt.exactAttempts {
+				t.Fatalf("expected to reach %d attempts, actual: %d", tt.exactAttempts, attempts)
+			}
+		})
+	}
+}
+
+type attemptsGuard struct {
+	r     *retrier
+	limit int
+}
+
+func (g *attemptsGuard) AllowRetry() bool {
+	return g.r.Attempts() < g.limit
+}
+
+func TestWithGuard_callsRetrier(t *testing.T) {
+	t.Parallel()
+	g := &attemptsGuard{limit: 2}
+	r := New(Constant{
+		Interval:    time.Millisecond,
+		MaxAttempts: 5,
+	}, WithGuard(g))
+	g.r = r
+	done := make(chan int)
+	go func() {
+		attempts := 0
+		for r.Next() {
+			attempts++
+		}
+		done <- attempts
+	}()
+	select {
+	case attempts := <-done:
+		if attempts != 2 {
+			t.Fatalf("expected to reach 2 attempts, actual: %d", attempts)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the guard calling the retrier deadlocked Next")
+	}
+}
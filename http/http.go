@@ -0,0 +1,180 @@
+// Package http retries HTTP requests by an http.RoundTripper.
+// It lives apart from package retry to keep the HTTP semantics out of the algorithms.
+package http
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/keisku/retry"
+)
+
+// Transport is an http.RoundTripper which retries requests failing to connect or losing the connection,
+// e.g. a refused or reset connection, or a response with a retryable status code.
+// Any other error, e.g. a TLS handshake failure or an unknown host, is returned immediately.
+// You can set empty for any fields, it will use default values.
+//
+// Only idempotent requests are retried unless RetryNonIdempotent is set: GET, HEAD, OPTIONS, TRACE, PUT, DELETE
+// and requests with an Idempotency-Key or X-Idempotency-Key header. The body of a retried request
+// is buffered unless GetBody is given, so that every attempt sends it again.
+//
+// When retrying stops, Transport returns the last response or error.
+// Every other response is drained up to 4KB and closed before the next attempt,
+// so that a large body closes its connection rather than being read in full.
+type Transport struct {
+	// Base performs every attempt. Default is http.DefaultTransport.
+	Base http.RoundTripper
+	// Algorithm computes the intervals between attempts. Default is retry.Jitter{MaxAttempts: 3}.
+	// The loop is bounded by the context of the request, which replaces Context of the algorithm,
+	// so give MaxAttempts or a deadline to the request not to retry forever.
+	Algorithm retry.Algorithm
+	// Options are given to retry.New along with the algorithm.
+	Options []retry.Option
+	// StatusCodes are the status codes of responses to retry. Default is 429, 502, 503 and 504.
+	StatusCodes []int
+	// RetryNonIdempotent retries requests of any method, e.g. POST to an API deduplicating them by itself.
+	RetryNonIdempotent bool
+	// BackoffFromResponse returns the duration a server asked to wait before the next attempt if any,
+	// which overrides the interval of the algorithm, e.g. by X-RateLimit-Reset.
+	// The duration is capped by Max of the algorithm if any, e.g. 15s by default of retry.Jitter,
+	// not to let a server stall the client, so raise Max to honor longer delays.
+	// Default is RetryAfter.
+	BackoffFromResponse func(resp *http.Response) (time.Duration, bool)
+}
+
+// RoundTrip performs req, retrying it as described in Transport.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !t.RetryNonIdempotent && !idempotent(req) {
+		return base.RoundTrip(req)
+	}
+	getBody, err := bodyGetter(req)
+	if err != nil {
+		return nil, err
+	}
+	a := t.Algorithm
+	if a == nil {
+		a = retry.Jitter{MaxAttempts: 3}
+	}
+	codes := t.StatusCodes
+	if len(codes) == 0 {
+		codes = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	}
+	backoff := t.BackoffFromResponse
+	if backoff == nil {
+		backoff = RetryAfter
+	}
+	r := retry.New(a, append(slices.Clip(t.Options), retry.WithContext(req.Context()))...)
+	var resp *http.Response
+	for r.Next() {
+		if resp != nil {
+			discard(resp)
+		}
+		attempt := req.Clone(req.Context())
+		if getBody != nil {
+			if attempt.Body, err = getBody(); err != nil {
+				return nil, err
+			}
+		}
+		resp, err = base.RoundTrip(attempt)
+		if err != nil {
+			if req.Context().Err() != nil || !connectionError(err) {
+				return nil, err
+			}
+			r.SetErr(err)
+			continue
+		}
+		if !slices.Contains(codes, resp.StatusCode) {
+			return resp, nil
+		}
+		if d, ok := backoff(resp); ok {
+			r.SetNextDelay(d)
+		}
+	}
+	if resp == nil && err == nil {
+		// Retrying stopped before the first attempt, i.e. the context is done.
+		return nil, req.Context().Err()
+	}
+	return resp, err
+}
+
+// RetryAfter returns the duration the Retry-After header of resp asks to wait,
+// given either in seconds or as an HTTP date.
+func RetryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(v); err == nil && 0 <= seconds {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// idempotent reports whether req can be sent more than once without changing its effect.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, key := req.Header["Idempotency-Key"]
+	_, xKey := req.Header["X-Idempotency-Key"]
+	return key || xKey
+}
+
+// bodyGetter returns a function returning a fresh copy of the body of req, or nil if it has none.
+// The body is buffered unless GetBody is given.
+func bodyGetter(req *http.Request) (func() (io.ReadCloser, error), error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		return req.GetBody, nil
+	}
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	return func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}, nil
+}
+
+// connectionError reports whether err tells that the request failed to connect or lost the connection,
+// so that another attempt may reach the server.
+func connectionError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// maxDiscard is the maximum number of bytes read from a response discarded before a retry.
+const maxDiscard = 4 << 10
+
+// discard drains and closes the body of resp so that its connection can be reused.
+// A body longer than maxDiscard is closed without reading the rest, which closes the connection.
+func discard(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDiscard))
+	_ = resp.Body.Close()
+}
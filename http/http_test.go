@@ -0,0 +1,265 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/keisku/retry"
+)
+
+var errTest = errors.New("test")
+
+// roundTripperFunc performs a request by a function.
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name           string
+		method         string
+		header         http.Header
+		transport      Transport
+		statuses       []int
+		headers        []http.Header
+		expectedStatus int
+		expectedCalls  int
+	}{
+		{
+			name:           "succeeds after retries",
+			method:         http.MethodGet,
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			expectedStatus: http.StatusOK,
+			expectedCalls:  3,
+		},
+		{
+			name:           "never succeeds",
+			method:         http.MethodGet,
+			statuses:       []int{http.StatusTooManyRequests, http.StatusTooManyRequests, http.StatusTooManyRequests},
+			expectedStatus: http.StatusTooManyRequests,
+			expectedCalls:  3,
+		},
+		{
+			name:           "not retryable status",
+			method:         http.MethodGet,
+			statuses:       []int{http.StatusInternalServerError},
+			expectedStatus: http.StatusInternalServerError,
+			expectedCalls:  1,
+		},
+		{
+			name:           "status codes",
+			method:         http.MethodGet,
+			transport:      Transport{StatusCodes: []int{http.StatusInternalServerError}},
+			statuses:       []int{http.StatusInternalServerError, http.StatusOK},
+			expectedStatus: http.StatusOK,
+			expectedCalls:  2,
+		},
+		{
+			name:           "non-idempotent",
+			method:         http.MethodPost,
+			statuses:       []int{http.StatusServiceUnavailable},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedCalls:  1,
+		},
+		{
+			name:           "non-idempotent opted in",
+			method:         http.MethodPost,
+			transport:      Transport{RetryNonIdempotent: true},
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatus: http.StatusOK,
+			expectedCalls:  2,
+		},
+		{
+			name:           "idempotency key",
+			method:         http.MethodPost,
+			header:         http.Header{"Idempotency-Key": {"1"}},
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusOK},
+			expectedStatus: http.StatusOK,
+			expectedCalls:  2,
+		},
+		{
+			name:           "retry after",
+			method:         http.MethodGet,
+			transport:      Transport{Algorithm: retry.Constant{Interval: time.Hour, MaxAttempts: 3}},
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusOK},
+			headers:        []http.Header{{"Retry-After": {"0"}}},
+			expectedStatus: http.StatusOK,
+			expectedCalls:  2,
+		},
+		{
+			name:   "backoff from response",
+			method: http.MethodGet,
+			transport: Transport{
+				Algorithm: retry.Constant{Interval: time.Hour, MaxAttempts: 3},
+				BackoffFromResponse: func(resp *http.Response) (time.Duration, bool) {
+					ms, err := strconv.Atoi(resp.Header.Get("X-Backoff"))
+					return time.Duration(ms) * time.Millisecond, err == nil
+				},
+			},
+			statuses:       []int{http.StatusServiceUnavailable, http.StatusOK},
+			headers:        []http.Header{{"X-Backoff": {"1"}}},
+			expectedStatus: http.StatusOK,
+			expectedCalls:  2,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			calls := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != "body" {
+					t.Errorf("expected the body to be sent on every attempt, actual: %q", body)
+				}
+				if calls < len(tt.headers) {
+					for k, vs := range tt.headers[calls] {
+						w.Header()[k] = vs
+					}
+				}
+				w.WriteHeader(tt.statuses[calls])
+				calls++
+			}))
+			defer server.Close()
+			transport := tt.transport
+			if transport.Algorithm == nil {
+				transport.Algorithm = retry.Constant{Interval: time.Millisecond, MaxAttempts: 3}
+			}
+			req, err := http.NewRequest(tt.method, server.URL, io.NopCloser(strings.NewReader("body")))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, vs := range tt.header {
+				req.Header[k] = vs
+			}
+			resp, err := (&http.Client{Transport: &transport}).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d, actual: %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if calls != tt.expectedCalls {
+				t.Fatalf("expected %d calls, actual: %d", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+func TestTransport_error(t *testing.T) {
+	t.Parallel()
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	tests := []struct {
+		name          string
+		err           error
+		expectedCalls int
+	}{
+		{name: "refused connection", err: refused, expectedCalls: 3},
+		{name: "reset connection", err: fmt.Errorf("read: %w", syscall.ECONNRESET), expectedCalls: 3},
+		{name: "unknown host", err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", IsNotFound: true}}, expectedCalls: 1},
+		{name: "other error", err: errTest, expectedCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			transport := &Transport{
+				Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					calls++
+					return nil, tt.err
+				}),
+				Algorithm: retry.Constant{Interval: time.Millisecond, MaxAttempts: 3},
+			}
+			req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := transport.RoundTrip(req); !errors.Is(err, tt.err) {
+				t.Fatalf("expected %v, actual: %v", tt.err, err)
+			}
+			if calls != tt.expectedCalls {
+				t.Fatalf("expected %d calls, actual: %d", tt.expectedCalls, calls)
+			}
+		})
+	}
+}
+
+// countingBody counts the bytes read from an endless body.
+type countingBody struct {
+	read   int
+	closed bool
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	b.read += len(p)
+	return len(p), nil
+}
+
+func (b *countingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestTransport_discard(t *testing.T) {
+	t.Parallel()
+	body := &countingBody{}
+	calls := 0
+	transport := &Transport{
+		Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			if calls == 1 {
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}, Body: body}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody}, nil
+		}),
+		Algorithm: retry.Constant{Interval: time.Millisecond, MaxAttempts: 3},
+	}
+	req, err := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected %d, actual: %v %v", http.StatusOK, resp, err)
+	}
+	if maxDiscard < body.read || !body.closed {
+		t.Fatalf("expected to read at most %d bytes and close the body, actual: %d bytes, closed: %t", maxDiscard, body.read, body.closed)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		value      string
+		expected   time.Duration
+		expectedOK bool
+	}{
+		{name: "none"},
+		{name: "seconds", value: "120", expected: 2 * time.Minute, expectedOK: true},
+		{name: "past date", value: "Sun, 06 Nov 1994 08:49:37 GMT", expected: 0, expectedOK: true},
+		{name: "malformed", value: "soon"},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.value != "" {
+			resp.Header.Set("Retry-After", tt.value)
+		}
+		d, ok := RetryAfter(resp)
+		if d != tt.expected || ok != tt.expectedOK {
+			t.Fatalf("%s: expected %s %t, actual: %s %t", tt.name, tt.expected, tt.expectedOK, d, ok)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package retry
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LatencyRecorder aggregates how long the Do helpers took including retries,
+// e.g. to answer how long operations typically take once retries are included.
+// It keeps the latest samples within a window and is safe for concurrent use.
+type LatencyRecorder struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	// next is the index of samples to overwrite once the window is full.
+	next int
+}
+
+// NewLatencyRecorder creates a LatencyRecorder which keeps the latest window samples.
+func NewLatencyRecorder(window int) *LatencyRecorder {
+	if window < 1 {
+		window = 1
+	}
+	return &LatencyRecorder{
+		samples: make([]time.Duration, 0, window),
+	}
+}
+
+// Record adds a sample.
+func (l *LatencyRecorder) Record(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.samples) < cap(l.samples) {
+		l.samples = append(l.samples, d)
+		return
+	}
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % len(l.samples)
+}
+
+// Percentile returns the p-th percentile, e.g. 99 for p99, of the samples
+// by the nearest-rank method. It returns zero if there are no samples.
+func (l *LatencyRecorder) Percentile(p float64) time.Duration {
+	l.mu.Lock()
+	sorted := make([]time.Duration, len(l.samples))
+	copy(sorted, l.samples)
+	l.mu.Unlock()
+	if len(sorted) == 0 {
+		return 0
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if len(sorted) < rank {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// WithLatencyRecorder records the elapsed time of every Do call, including retries, to l.
+func WithLatencyRecorder(l *LatencyRecorder) Option {
+	return func(r *retrier) {
+		r.latency = l
+	}
+}
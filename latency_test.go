@@ -0,0 +1,69 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLatencyRecorder_Percentile(t *testing.T) {
+	t.Parallel()
+	l := NewLatencyRecorder(100)
+	if p := l.Percentile(50); p != 0 {
+		t.Fatalf("expected zero without samples, actual: %s", p)
+	}
+	var wg sync.WaitGroup
+	for i := 1; i <= 100; i++ {
+		wg.Add(1)
+		go func(d time.Duration) {
+			defer wg.Done()
+			l.Record(d)
+		}(time.Duration(i) * time.Millisecond)
+	}
+	wg.Wait()
+	tests := []struct {
+		p        float64
+		expected time.Duration
+	}{
+		{p: 0, expected: time.Millisecond},
+		{p: 50, expected: 50 * time.Millisecond},
+		{p: 90, expected: 90 * time.Millisecond},
+		{p: 99, expected: 99 * time.Millisecond},
+		{p: 100, expected: 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if actual := l.Percentile(tt.p); actual != tt.expected {
+			t.Fatalf("expected p%v to be %s, actual: %s", tt.p, tt.expected, actual)
+		}
+	}
+}
+
+func TestLatencyRecorder_window(t *testing.T) {
+	t.Parallel()
+	l := NewLatencyRecorder(10)
+	for i := 1; i <= 20; i++ {
+		l.Record(time.Duration(i) * time.Millisecond)
+	}
+	if p := l.Percentile(0); p != 11*time.Millisecond {
+		t.Fatalf("expected to keep the latest 10 samples from 11ms, actual: %s", p)
+	}
+	if p := l.Percentile(100); p != 20*time.Millisecond {
+		t.Fatalf("expected to keep the latest 10 samples up to 20ms, actual: %s", p)
+	}
+}
+
+func TestWithLatencyRecorder(t *testing.T) {
+	t.Parallel()
+	l := NewLatencyRecorder(10)
+	interval := 5 * time.Millisecond
+	_ = Do(Constant{
+		Interval:    interval,
+		MaxAttempts: 3,
+	}, func() error {
+		return errors.New("error")
+	}, WithLatencyRecorder(l))
+	if p := l.Percentile(100); p < 2*interval {
+		t.Fatalf("expected to record latency including 2 retries, actual: %s", p)
+	}
+}
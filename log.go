@@ -0,0 +1,109 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// WithLogger logs every attempt and the moment the retrier gives up.
+// The Do helpers also log errors of failed attempts, where consecutive identical errors
+// are logged once with a repeat count such as "connection refused (x5)".
+func WithLogger(l *slog.Logger) Option {
+	return func(r *retrier) {
+		r.logger = l
+	}
+}
+
+// WithLogEvery throttles attempt logging to the first attempt and every n-th attempt.
+// Giving up is always logged. It is useful for timeout-based loops
+// that may retry hundreds of times.
+func WithLogEvery(n int) Option {
+	return func(r *retrier) {
+		r.logEvery = n
+	}
+}
+
+func (r *retrier) logAttempt() {
+	if r.logger == nil {
+		return
+	}
+	attempt := r.attempts + 1
+	if attempt != 1 && r.logEvery > 1 && attempt%r.logEvery != 0 {
+		return
+	}
+	r.logger.Info("retry attempt", slog.Int("attempt", attempt))
+}
+
+// loggerKey is the context key for the logger passed by DoContext.
+type loggerKey struct{}
+
+// Logger returns the logger carried by the context DoContext passes to the function,
+// so that the logs of every attempt are tagged with its number as retry.attempt.
+// It is derived from the logger given by WithLogger, or slog.Default.
+// It returns slog.Default if ctx carries no logger.
+func Logger(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// attemptContext returns the context of the current attempt carrying the tagged logger,
+// bounded by Deadline unless Context is given and by the per-attempt timeout if any.
+// Call cancel once the attempt returns.
+func (r *retrier) attemptContext() (ctx context.Context, cancel context.CancelFunc) {
+	l := r.logger
+	if l == nil {
+		l = slog.Default()
+	}
+	ctx = context.WithValue(r.ctx, loggerKey{}, l.With(slog.Int("retry.attempt", r.attempts)))
+	cancelDeadline := func() {}
+	if r.ownContext && !r.deadline.IsZero() {
+		ctx, cancelDeadline = context.WithDeadline(ctx, r.deadline)
+	}
+	if r.attemptTimeout > 0 {
+		ctx, cancelAttempt := context.WithTimeout(ctx, r.attemptTimeout)
+		return ctx, func() {
+			cancelAttempt()
+			cancelDeadline()
+		}
+	}
+	return ctx, cancelDeadline
+}
+
+// logError logs err unless it is the same as the previous one, which is counted instead.
+func (r *retrier) logError(err error) {
+	if r.logger == nil {
+		return
+	}
+	msg := err.Error()
+	if r.repeats > 0 && msg == r.lastErr {
+		r.repeats++
+		return
+	}
+	r.flushError()
+	r.lastErr = msg
+	r.repeats = 1
+}
+
+// flushError logs the pending error with its repeat count.
+func (r *retrier) flushError() {
+	if r.logger == nil || r.repeats == 0 {
+		return
+	}
+	msg := r.lastErr
+	if r.repeats > 1 {
+		msg = fmt.Sprintf("%s (x%d)", msg, r.repeats)
+	}
+	r.logger.Info("retry attempt failed", slog.String("error", msg), slog.Int("repeats", r.repeats))
+	r.repeats = 0
+}
+
+func (r *retrier) logGiveUp() {
+	if r.logger == nil {
+		return
+	}
+	r.flushError()
+	r.logger.Warn("retry gave up", slog.Int("attempts", r.attempts))
+}
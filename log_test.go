@@ -0,0 +1,142 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithLogEvery(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	r := New(
+		Constant{
+			Interval:    time.Microsecond,
+			MaxAttempts: 25,
+		},
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+		WithLogEvery(10),
+	)
+	for r.Next() {
+	}
+	var logged []int
+	gaveUp := 0
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var line struct {
+			Msg      string
+			Attempt  int
+			Attempts int
+		}
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("%+v", line)
+		switch line.Msg {
+		case "retry attempt":
+			logged = append(logged, line.Attempt)
+		case "retry gave up":
+			gaveUp = line.Attempts
+		}
+	}
+	expected := []int{1, 10, 20}
+	if !reflect.DeepEqual(logged, expected) {
+		t.Fatalf("expected to log attempts %v, actual: %v", expected, logged)
+	}
+	if gaveUp != 25 {
+		t.Fatalf("expected to log giving up after 25 attempts, actual: %d", gaveUp)
+	}
+}
+
+func TestWithLogger_errors(t *testing.T) {
+	t.Parallel()
+	errA := errors.New("connection refused")
+	errB := errors.New("timeout")
+	tests := []struct {
+		name     string
+		errs     []error
+		expected []string
+	}{
+		{
+			name:     "identical",
+			errs:     []error{errA, errA, errA, errA, errA},
+			expected: []string{"connection refused (x5)"},
+		},
+		{
+			name:     "distinct",
+			errs:     []error{errA, errA, errB, errA},
+			expected: []string{"connection refused (x2)", "timeout", "connection refused"},
+		},
+		{
+			name:     "success",
+			errs:     []error{errB, errB, nil},
+			expected: []string{"timeout (x2)"},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			i := 0
+			_ = Do(Constant{Interval: time.Microsecond, MaxAttempts: len(tt.errs)}, func() error {
+				err := tt.errs[i]
+				i++
+				return err
+			}, WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+			var logged []string
+			dec := json.NewDecoder(&buf)
+			for dec.More() {
+				var line struct {
+					Msg   string
+					Error string
+				}
+				if err := dec.Decode(&line); err != nil {
+					t.Fatal(err)
+				}
+				if line.Msg == "retry attempt failed" {
+					logged = append(logged, line.Error)
+				}
+			}
+			if !reflect.DeepEqual(logged, tt.expected) {
+				t.Fatalf("expected to log errors %q, actual: %q", tt.expected, logged)
+			}
+		})
+	}
+}
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := DoContext(Constant{Interval: time.Microsecond, MaxAttempts: 3}, func(ctx context.Context) error {
+		Logger(ctx).Info("calling")
+		return errTest
+	}, WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))))
+	if !errors.Is(err, errTest) {
+		t.Fatalf("expected %v, actual: %v", errTest, err)
+	}
+	var tagged []int
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var line map[string]any
+		if err := dec.Decode(&line); err != nil {
+			t.Fatal(err)
+		}
+		if line["msg"] == "calling" {
+			attempt, _ := line["retry.attempt"].(float64)
+			tagged = append(tagged, int(attempt))
+		}
+	}
+	expected := []int{1, 2, 3}
+	if !reflect.DeepEqual(tagged, expected) {
+		t.Fatalf("expected logs tagged with attempts %v, actual: %v", expected, tagged)
+	}
+	if Logger(context.Background()) != slog.Default() {
+		t.Fatal("expected the default logger without DoContext")
+	}
+}
@@ -0,0 +1,46 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// WithContext replaces Context of the algorithm,
+// e.g. New(Constant{}, WithContext(ctx)) instead of repeating the whole struct literal.
+// A jittered algorithm draws its seed from ctx as if it were given as Context, e.g. set by WithSeed.
+func WithContext(ctx context.Context) Option {
+	return func(r *retrier) {
+		r.ctx = ctx
+		if s, ok := r.calculator.(seeder); ok {
+			s.reseed(ctx)
+		}
+	}
+}
+
+// WithMaxAttempts replaces MaxAttempts of the algorithm.
+func WithMaxAttempts(n int) Option {
+	return func(r *retrier) {
+		r.maxAttempts = n
+	}
+}
+
+// WithDefaultMaxAttempts sets MaxAttempts to n if nothing else bounds the loop, i.e. neither the algorithm
+// nor the options given before it set MaxAttempts, Deadline or MaxElapsedTime, or WithoutTimeout.
+// It suits a loop given a Context which may never be done, e.g. by a library retrying on behalf of its caller.
+func WithDefaultMaxAttempts(n int) Option {
+	return func(r *retrier) {
+		if r.maxAttempts == 0 && r.deadline.IsZero() && r.maxElapsed == 0 && !r.noTimeout {
+			r.maxAttempts = n
+		}
+	}
+}
+
+// WithMax replaces Max of the algorithm, or the default Max if max is 0.
+// Base is capped by it as well. It has no effect on algorithms without Max, e.g. Constant.
+func WithMax(max time.Duration) Option {
+	return func(r *retrier) {
+		if c, ok := r.calculator.(capper); ok {
+			c.setMax(max)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithContext(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := New(Constant{}, WithContext(ctx), WithClock(&fakeClock{}))
+	attempts := 0
+	for r.Next() {
+		attempts++
+	}
+	if attempts != 1 || r.reason != ContextCanceled {
+		t.Fatalf("expected to stop by %s after 1 attempt, actual: %s after %d", ContextCanceled, r.reason, attempts)
+	}
+}
+
+func TestWithMaxAttempts(t *testing.T) {
+	t.Parallel()
+	r := New(Constant{MaxAttempts: 10}, WithMaxAttempts(3), WithClock(&fakeClock{}))
+	attempts := 0
+	for r.Next() {
+		attempts++
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, actual: %d", attempts)
+	}
+	if r.ctx.Done() != nil {
+		t.Fatal("expected MaxAttempts given by the option to disable the default timeout")
+	}
+}
+
+func TestWithDefaultMaxAttempts(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		algorithm Algorithm
+		opts      []Option
+		expected  int
+	}{
+		{
+			name:      "unbounded",
+			algorithm: Constant{},
+			expected:  3,
+		},
+		{
+			name:      "MaxAttempts",
+			algorithm: Constant{MaxAttempts: 5},
+			expected:  5,
+		},
+		{
+			name:      "MaxElapsedTime",
+			algorithm: Constant{Interval: time.Second, MaxElapsedTime: 10 * time.Second},
+			expected:  11,
+		},
+		{
+			name:      "WithMaxAttempts before",
+			algorithm: Constant{},
+			opts:      []Option{WithMaxAttempts(4)},
+			expected:  4,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			opts := append(tt.opts, WithDefaultMaxAttempts(3), WithContext(context.Background()), WithClock(&fakeClock{}))
+			r := New(tt.algorithm, opts...)
+			attempts := 0
+			for r.Next() {
+				attempts++
+			}
+			if attempts != tt.expected {
+				t.Fatalf("expected %d attempts, actual: %d", tt.expected, attempts)
+			}
+		})
+	}
+}
+
+func TestWithMax(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		algorithm Algorithm
+		max       time.Duration
+		expected  time.Duration
+	}{
+		{
+			name:      "replaces Max",
+			algorithm: Linear{Base: time.Second, Max: time.Minute},
+			max:       3 * time.Second,
+			expected:  3 * time.Second,
+		},
+		{
+			name:      "default",
+			algorithm: Fibonacci{Max: time.Minute},
+			expected:  15 * time.Second,
+		},
+		{
+			name:      "caps Base",
+			algorithm: Jitter{Base: time.Minute},
+			max:       time.Second,
+			expected:  time.Second,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := New(tt.algorithm, WithMax(tt.max))
+			for i := 0; i < 100; i++ {
+				if d := r.calc(); tt.expected < d {
+					t.Fatalf("calc %d, expected not to exceed %s, actual: %s", i, tt.expected, d)
+				}
+			}
+			if max := r.calculator.(capper).maxInterval(); max != tt.expected {
+				t.Fatalf("expected Max %s, actual: %s", tt.expected, max)
+			}
+		})
+	}
+}
+
+func TestWithMax_noMax(t *testing.T) {
+	t.Parallel()
+	r := New(Constant{Interval: time.Minute}, WithMax(time.Second))
+	if d := r.calc(); d != time.Minute {
+		t.Fatalf("expected Constant to keep its interval, actual: %s", d)
+	}
+}
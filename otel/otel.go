@@ -0,0 +1,52 @@
+// Package otel traces retry loops with OpenTelemetry.
+// It lives apart from package retry so that only its users depend on OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/keisku/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Do behaves like retry.DoContext within a span named name started by tracer as a child of ctx.
+// Every attempt is recorded as an "attempt" event of the span with its number and error if it failed,
+// including the last one, and every retry as a "retry" event with the interval to wait.
+// The span ends with an error status if fn never succeeds.
+// fn receives the context of the retrier carrying the span.
+//
+// ctx bounds the loop, which replaces Context of the algorithm, so a canceled ctx stops retrying.
+// Do records the retries by retry.WithNotify, so it replaces a notify given in opts.
+func Do(ctx context.Context, tracer trace.Tracer, name string, a retry.Algorithm, fn func(ctx context.Context) error, opts ...retry.Option) error {
+	ctx, span := tracer.Start(ctx, name)
+	defer span.End()
+	attempts := 0
+	err := retry.DoContext(a, func(actx context.Context) error {
+		attempts++
+		err := fn(actx)
+		attrs := []attribute.KeyValue{attribute.Int("retry.attempt", attempts)}
+		if err != nil {
+			attrs = append(attrs, attribute.String("retry.error", err.Error()))
+		}
+		span.AddEvent("attempt", trace.WithAttributes(attrs...))
+		return err
+	}, append(slices.Clip(opts), retry.WithContext(ctx), retry.WithNotify(func(err error, attempt int, next time.Duration) {
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("retry.attempt", attempt),
+			attribute.String("retry.error", err.Error()),
+			attribute.Int64("retry.delay_ms", next.Milliseconds()),
+		))
+	}))...)
+	span.SetAttributes(attribute.Int("retry.attempts", attempts))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	span.SetStatus(codes.Ok, "")
+	return nil
+}
@@ -0,0 +1,133 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/keisku/retry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errTest = errors.New("test")
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name           string
+		failures       int
+		expectedErr    error
+		expectedEvents int
+		expectedFailed int
+		expectedStatus codes.Code
+	}{
+		{
+			name:           "succeeds after retries",
+			failures:       2,
+			expectedEvents: 2,
+			expectedFailed: 2,
+			expectedStatus: codes.Ok,
+		},
+		{
+			name:           "never succeeds",
+			failures:       3,
+			expectedErr:    errTest,
+			expectedEvents: 2,
+			expectedFailed: 3,
+			expectedStatus: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			recorder := tracetest.NewSpanRecorder()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+			calls := 0
+			err := Do(context.Background(), tracer, "op", retry.Constant{
+				Interval:    time.Millisecond,
+				MaxAttempts: 3,
+			}, func(ctx context.Context) error {
+				if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+					t.Fatal("expected the context to carry the span")
+				}
+				calls++
+				if calls <= tt.failures {
+					return errTest
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.expectedErr) {
+				t.Fatalf("expected %v, actual: %v", tt.expectedErr, err)
+			}
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("expected 1 span, actual: %d", len(spans))
+			}
+			span := spans[0]
+			if span.Status().Code != tt.expectedStatus {
+				t.Fatalf("expected status %v, actual: %v", tt.expectedStatus, span.Status().Code)
+			}
+			var retries, attempts, failed int
+			for _, e := range span.Events() {
+				if e.Name == "attempt" {
+					attempts++
+					for _, attr := range e.Attributes {
+						if attr.Key == "retry.error" {
+							failed++
+						}
+					}
+					continue
+				}
+				if e.Name != "retry" {
+					continue
+				}
+				retries++
+				for _, attr := range e.Attributes {
+					if attr.Key == "retry.attempt" && attr.Value.AsInt64() != int64(retries) {
+						t.Fatalf("expected retry event %d for attempt %d, actual: %d", retries, retries, attr.Value.AsInt64())
+					}
+				}
+			}
+			if retries != tt.expectedEvents {
+				t.Fatalf("expected %d retry events, actual: %d", tt.expectedEvents, retries)
+			}
+			if attempts != calls || failed != tt.expectedFailed {
+				t.Fatalf("expected %d attempt events with %d failed, actual: %d with %d failed", calls, tt.expectedFailed, attempts, failed)
+			}
+			expected := attribute.Int("retry.attempts", calls)
+			found := false
+			for _, attr := range span.Attributes() {
+				found = found || attr == expected
+			}
+			if !found {
+				t.Fatalf("expected attribute %v, actual: %v", expected, span.Attributes())
+			}
+		})
+	}
+}
+
+func TestDo_canceled(t *testing.T) {
+	t.Parallel()
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	err := Do(ctx, tracer, "op", retry.Constant{Interval: time.Hour, MaxAttempts: 3}, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return errTest
+	})
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, errTest) {
+		t.Fatalf("expected %v with %v, actual: %v", context.Canceled, errTest, err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the canceled context to stop retrying, actual: %d calls", calls)
+	}
+}
@@ -0,0 +1,49 @@
+package retry
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is an error converted from a panic recovered by the Do helpers.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine which panicked.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("retry: recovered from panic: %v", e.Value)
+}
+
+// Unwrap returns Value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// WithRecoverPanic makes the Do helpers recover a panic in the function
+// and treat it as a *PanicError, which is retried or classified like any other error.
+// It protects long-running workers from crashing on a transient panic.
+// By default, a panic propagates to the caller.
+func WithRecoverPanic() Option {
+	return func(r *retrier) {
+		r.recoverPanic = true
+	}
+}
+
+// call calls fn, converting a panic into *PanicError if r recovers panics.
+func call[T any](r *retrier, fn func() (T, error)) (v T, err error) {
+	if r.recoverPanic {
+		defer func() {
+			if p := recover(); p != nil {
+				err = &PanicError{
+					Value: p,
+					Stack: debug.Stack(),
+				}
+			}
+		}()
+	}
+	return fn()
+}
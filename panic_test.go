@@ -0,0 +1,70 @@
+package retry
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// panicN returns a function that panics with v n times and then succeeds.
+func panicN(n int, v any) func() error {
+	calls := 0
+	return func() error {
+		calls++
+		if calls <= n {
+			panic(v)
+		}
+		return nil
+	}
+}
+
+func TestWithRecoverPanic(t *testing.T) {
+	t.Parallel()
+	a := Constant{
+		Interval:    time.Millisecond,
+		MaxAttempts: 3,
+	}
+	t.Run("recover and retry", func(t *testing.T) {
+		res, err := DoResult(a, panicN(2, errTest), WithRecoverPanic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Attempts != 3 {
+			t.Fatalf("expected to succeed at 3rd attempt, actual: %d", res.Attempts)
+		}
+		for _, err := range res.Errors {
+			var perr *PanicError
+			if !errors.As(err, &perr) {
+				t.Fatalf("expected *PanicError, actual: %T", err)
+			}
+			if !errors.Is(err, errTest) {
+				t.Fatalf("expected to unwrap to %v, actual: %v", errTest, err)
+			}
+		}
+	})
+	t.Run("recover and classify", func(t *testing.T) {
+		res, err := DoResult(a, panicN(3, "boom"), WithRecoverPanic(), WithClassifier(func(err error) Decision {
+			var perr *PanicError
+			if errors.As(err, &perr) {
+				return NoRetry
+			}
+			return RetryWithBackoff
+		}))
+		var perr *PanicError
+		if !errors.As(err, &perr) || perr.Value != "boom" {
+			t.Fatalf("expected *PanicError of boom, actual: %v", err)
+		}
+		if res.Attempts != 1 {
+			t.Fatalf("expected not to retry a panic, actual: %d attempts", res.Attempts)
+		}
+	})
+	t.Run("propagate", func(t *testing.T) {
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Fatalf("expected to propagate the panic, actual: %v", p)
+			}
+		}()
+		_ = Do(a, panicN(1, "boom"))
+		t.Fatal("expected to panic")
+	})
+}
@@ -0,0 +1,36 @@
+package retry
+
+import "errors"
+
+// PermanentError wraps an error which is hopeless to retry, see Permanent.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent wraps err so that the Do helpers stop retrying immediately,
+// e.g. on an authentication failure. They return err itself to the caller.
+// Permanent returns nil if err is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+// unwrapPermanent returns the error wrapped by Permanent, or err if it is not permanent.
+func unwrapPermanent(err error) error {
+	var perm *PermanentError
+	if errors.As(err, &perm) {
+		return perm.Err
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPermanent(t *testing.T) {
+	t.Parallel()
+	errAuth := errors.New("unauthorized")
+	tests := []struct {
+		name             string
+		err              error
+		expectedAttempts int
+	}{
+		{
+			name:             "permanent",
+			err:              Permanent(errAuth),
+			expectedAttempts: 1,
+		},
+		{
+			name:             "wrapped permanent",
+			err:              fmt.Errorf("login: %w", Permanent(errAuth)),
+			expectedAttempts: 1,
+		},
+		{
+			name:             "transient",
+			err:              errAuth,
+			expectedAttempts: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := DoResult(Constant{
+				Interval:    time.Millisecond,
+				MaxAttempts: 3,
+			}, func() error {
+				return tt.err
+			})
+			if err != errAuth {
+				t.Fatalf("expected the original error %v, actual: %#v", errAuth, err)
+			}
+			if res.Attempts != tt.expectedAttempts {
+				t.Fatalf("expected %d attempts, actual: %d", tt.expectedAttempts, res.Attempts)
+			}
+		})
+	}
+	if Permanent(nil) != nil {
+		t.Fatal("expected nil for a nil error")
+	}
+	if err := Permanent(errAuth); !errors.Is(err, errAuth) || errors.Unwrap(err) != errAuth {
+		t.Fatalf("expected to unwrap to %v, actual: %v", errAuth, err)
+	}
+	if (Policy{}).WouldRetry(Permanent(errAuth)) {
+		t.Fatal("expected a permanent error not to be retried")
+	}
+}
@@ -0,0 +1,38 @@
+package retry
+
+import "errors"
+
+// ProgressError wraps an error of an attempt which made partial progress, see Progress.
+type ProgressError struct {
+	Err error
+}
+
+func (e *ProgressError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *ProgressError) Unwrap() error {
+	return e.Err
+}
+
+// Progress wraps err so that the Do helpers retry it with the interval starting over
+// from the base interval, like the Progress method in a Next loop,
+// e.g. when some chunks of a resumable upload were sent before the attempt failed.
+// They handle err itself otherwise, e.g. by Policy, and return it to the caller.
+// Progress returns nil if err is nil.
+func Progress(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ProgressError{Err: err}
+}
+
+// unwrapProgress returns the error wrapped by Progress and true, or err and false if it made no progress.
+func unwrapProgress(err error) (error, bool) {
+	var progress *ProgressError
+	if errors.As(err, &progress) {
+		return progress.Err, true
+	}
+	return err, false
+}
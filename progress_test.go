@@ -0,0 +1,57 @@
+package retry
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name          string
+		err           error
+		expectedWaits []time.Duration
+	}{
+		{
+			name:          "progress",
+			err:           Progress(errTest),
+			expectedWaits: []time.Duration{750 * time.Millisecond, 750 * time.Millisecond, 750 * time.Millisecond},
+		},
+		{
+			name:          "no progress",
+			err:           errTest,
+			expectedWaits: []time.Duration{750 * time.Millisecond, 1500 * time.Millisecond, 3 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{}
+			res, err := DoResult(ExponentialBackoff{
+				Base:          time.Second,
+				Max:           time.Hour,
+				Deterministic: true,
+				StartAtBase:   true,
+				MaxAttempts:   4,
+			}, func() error {
+				return tt.err
+			}, WithClock(clock))
+			if err != errTest {
+				t.Fatalf("expected the original error %v, actual: %#v", errTest, err)
+			}
+			if res.Attempts != 4 {
+				t.Fatalf("expected 4 attempts, actual: %d", res.Attempts)
+			}
+			if !reflect.DeepEqual(clock.waits, tt.expectedWaits) {
+				t.Fatalf("expected waits %v, actual: %v", tt.expectedWaits, clock.waits)
+			}
+		})
+	}
+	if Progress(nil) != nil {
+		t.Fatal("expected nil for a nil error")
+	}
+	if err := Progress(errTest); !errors.Is(err, errTest) || errors.Unwrap(err) != errTest {
+		t.Fatalf("expected to unwrap to %v, actual: %v", errTest, err)
+	}
+}
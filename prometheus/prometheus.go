@@ -0,0 +1,72 @@
+// Package prometheus exports metrics of retry loops to Prometheus.
+// It lives apart from package retry so that only its users depend on the Prometheus client.
+package prometheus
+
+import (
+	"time"
+
+	"github.com/keisku/retry"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics are the collectors fed by the Do helpers given Options.
+type Metrics struct {
+	retries  prom.Counter
+	attempts prom.Histogram
+	giveUps  prom.Counter
+}
+
+// NewMetrics creates the collectors below prefixed by namespace and registers them to reg.
+//
+//   - retry_retries_total counts retries, which excludes the first attempts.
+//   - retry_attempts_until_success observes the number of attempts of loops that succeeded.
+//   - retry_give_ups_total counts loops that stopped without success.
+func NewMetrics(reg prom.Registerer, namespace string) (*Metrics, error) {
+	m := &Metrics{
+		retries: prom.NewCounter(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "retry_retries_total",
+			Help:      "Total number of retries.",
+		}),
+		attempts: prom.NewHistogram(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "retry_attempts_until_success",
+			Help:      "Number of attempts until a retry loop succeeded.",
+			Buckets:   []float64{1, 2, 3, 5, 8, 13, 21},
+		}),
+		giveUps: prom.NewCounter(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "retry_give_ups_total",
+			Help:      "Total number of retry loops that stopped without success.",
+		}),
+	}
+	for _, c := range []prom.Collector{m.retries, m.attempts, m.giveUps} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Options returns the options feeding the metrics, e.g. retry.Do(a, fn, m.Options()...).
+// They use retry.WithOnRetry and retry.WithOnFinish, so they replace the ones given before them.
+func (m *Metrics) Options() []retry.Option {
+	return []retry.Option{
+		retry.WithOnRetry(m.OnRetry),
+		retry.WithOnFinish(m.OnFinish),
+	}
+}
+
+// OnRetry counts a retry. It is compatible with retry.WithOnRetry.
+func (m *Metrics) OnRetry(attempt int, next time.Duration) {
+	m.retries.Inc()
+}
+
+// OnFinish records the outcome of a loop. It is compatible with retry.WithOnFinish.
+func (m *Metrics) OnFinish(res retry.Result) {
+	if res.Succeeded {
+		m.attempts.Observe(float64(res.Attempts))
+		return
+	}
+	m.giveUps.Inc()
+}
@@ -0,0 +1,71 @@
+package prometheus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/keisku/retry"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var errTest = errors.New("test")
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+	reg := prom.NewRegistry()
+	m, err := NewMetrics(reg, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := retry.Constant{Interval: 1, MaxAttempts: 3}
+	calls := 0
+	// Succeeds at the second attempt.
+	_ = retry.Do(a, func() error {
+		calls++
+		if calls == 1 {
+			return errTest
+		}
+		return nil
+	}, m.Options()...)
+	// Gives up after 3 attempts.
+	_ = retry.Do(a, func() error {
+		return errTest
+	}, m.Options()...)
+
+	if v := testutil.ToFloat64(m.retries); v != 3 {
+		t.Fatalf("expected 3 retries, actual: %v", v)
+	}
+	if v := testutil.ToFloat64(m.giveUps); v != 1 {
+		t.Fatalf("expected 1 give-up, actual: %v", v)
+	}
+	if n := testutil.CollectAndCount(m.attempts); n != 1 {
+		t.Fatalf("expected 1 histogram, actual: %d", n)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() != "test_retry_attempts_until_success" {
+			continue
+		}
+		h := f.GetMetric()[0].GetHistogram()
+		if h.GetSampleCount() != 1 || h.GetSampleSum() != 2 {
+			t.Fatalf("expected 1 loop succeeded after 2 attempts, actual: %d loops, %v attempts", h.GetSampleCount(), h.GetSampleSum())
+		}
+		return
+	}
+	t.Fatal("expected the histogram to be registered")
+}
+
+func TestNewMetrics_duplicate(t *testing.T) {
+	t.Parallel()
+	reg := prom.NewRegistry()
+	if _, err := NewMetrics(reg, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMetrics(reg, "test"); err == nil {
+		t.Fatal("expected an error registering the same metrics twice")
+	}
+}
@@ -0,0 +1,66 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// globalLimiter caps the rate of retries made by every retrier in the process.
+var globalLimiter = &limiter{}
+
+// SetGlobalRetryRate caps the number of retries per second across the whole process.
+// Every retry, but not the first attempt, waits until the rate allows it
+// as long as the context of the retrier is not done.
+// This is a safety valve against retry storms from many independent call sites.
+// A non-positive rate removes the cap, which is the default.
+func SetGlobalRetryRate(perSecond float64) {
+	globalLimiter.setRate(perSecond)
+}
+
+// limiter spaces events evenly so that they don't exceed a rate.
+type limiter struct {
+	mu   sync.Mutex
+	rate float64
+	// last is the time reserved for the latest event.
+	last time.Time
+}
+
+func (l *limiter) setRate(perSecond float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = perSecond
+	l.last = time.Time{}
+}
+
+// reserve returns how long the caller should wait before the event.
+func (l *limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rate <= 0 {
+		return 0
+	}
+	now := time.Now()
+	at := l.last.Add(time.Duration(float64(time.Second) / l.rate))
+	if at.Before(now) {
+		at = now
+	}
+	l.last = at
+	return at.Sub(now)
+}
+
+// wait blocks until the rate allows an event. It returns false if ctx is done first.
+func (l *limiter) wait(ctx context.Context) bool {
+	d := l.reserve()
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,42 @@
+package retry
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetGlobalRetryRate(t *testing.T) {
+	const (
+		perSecond = 200
+		workers   = 4
+		retries   = 10
+	)
+	SetGlobalRetryRate(perSecond)
+	t.Cleanup(func() {
+		// reset not to effect other tests.
+		SetGlobalRetryRate(0)
+	})
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := New(Constant{
+				Interval:    time.Microsecond,
+				MaxAttempts: retries + 1,
+			})
+			for r.Next() {
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	// The first retry doesn't wait for the limiter.
+	least := time.Duration(workers*retries-1) * time.Second / perSecond
+	t.Logf("%d retries in %s", workers*retries, elapsed)
+	if elapsed < least {
+		t.Fatalf("expected %d retries to take %s at least, actual: %s", workers*retries, least, elapsed)
+	}
+}
@@ -2,17 +2,103 @@ package retry
 
 import (
 	"context"
+	"fmt"
+	"hash/fnv"
+	"iter"
+	"log/slog"
 	"math"
 	"math/rand"
+	"sync"
 	"time"
 )
 
 // retrier provides retry functionalities.
 type retrier struct {
+	// mu guards the state of the loop. It is not held while waiting or calling back middlewares,
+	// callbacks, the Guard and the Breaker, so that they and other goroutines can call the methods of the retrier.
+	// It is held while computing an interval, so the Clock, the time scale and a Backoff must not call them.
+	mu sync.Mutex
+	// turn serializes Next so that workers sharing a retrier take turns waiting.
+	turn sync.Mutex
 	calculator
-	ctx         context.Context
-	maxAttempts float64
-	attempts    float64
+	// algorithm and opts are given to New, which Clone repeats.
+	algorithm  Algorithm
+	opts       []Option
+	ctx        context.Context
+	deadline   time.Time
+	maxElapsed time.Duration
+	// initialDelay is the wait before the first attempt.
+	initialDelay time.Duration
+	maxAttempts  int
+	attempts     int
+	// started is the time of the first attempt.
+	started time.Time
+	// err is the error last recorded by SetErr.
+	err error
+
+	// slept is the total duration spent waiting between attempts.
+	slept  time.Duration
+	reason StopReason
+	// lastInterval is the interval waited before the latest attempt.
+	lastInterval time.Duration
+	clock        Clock
+	// sleepFunc replaces the clock for waiting if set.
+	sleepFunc func(ctx context.Context, d time.Duration) error
+	// sleepErr is the error of sleepFunc which stopped the loop.
+	sleepErr  error
+	noTimeout bool
+	// stats tracks the intervals waited if WithStats is given.
+	stats *intervalStats
+	// ownContext is set if the retrier created its context since no Context is given.
+	ownContext bool
+	// defaultTimeout bounds the loop when nothing else does.
+	defaultTimeout time.Duration
+	// cancelTimeout cancels the context created for the default timeout.
+	cancelTimeout context.CancelFunc
+
+	healthyPeriod time.Duration
+	// chained is Next wrapped by middlewares.
+	chained func() bool
+
+	timeScale func(now time.Time) float64
+	rounding  Rounding
+	grid      time.Duration
+	guard     Guard
+	keepGoing func(err error, attempt int) bool
+	// byDo is set while a Do helper runs the loop, which calls keepGoing in place of MaxAttempts.
+	byDo    bool
+	probe   func() error
+	breaker Breaker
+	// allowed is set while breaker has allowed an attempt which is not performed yet.
+	allowed bool
+	// attemptTimeout bounds every call of the context-aware Do helpers.
+	attemptTimeout time.Duration
+	policy         Policy
+	// recoverPanic converts a panic in the function of the Do helpers into an error.
+	recoverPanic bool
+	// maxJoined is the maximum number of errors joined by the Do helpers, see WithJoinedErrors.
+	maxJoined int
+	// skipWait makes the next retry happen without waiting.
+	skipWait bool
+	// nextDelay overrides the next interval if overrideDelay is set.
+	nextDelay     time.Duration
+	overrideDelay bool
+
+	latency  *LatencyRecorder
+	success  *SuccessTracker
+	logger   *slog.Logger
+	logEvery int
+	lastErr  string
+	repeats  int
+	gaveUp   bool
+	events   chan Event
+	// eventsClosed reports whether the loop has ended, so that events is closed.
+	eventsClosed bool
+	onRetry      func(attempt int, next time.Duration)
+	// attemptInfo is the channel given by WithAttemptInfo.
+	attemptInfo chan<- AttemptInfo
+	notify      func(err error, attempt int, next time.Duration)
+	finish      func(res Result)
 }
 
 // calculator calculates duration to wait for next retry.
@@ -20,58 +106,660 @@ type calculator interface {
 	calc() time.Duration
 }
 
+// capper is implemented by calculators that cap intervals by Max.
+type capper interface {
+	maxInterval() time.Duration
+	// setMax replaces Max, applying the default if max is 0.
+	setMax(max time.Duration)
+}
+
+// seeder is implemented by jittered calculators so that WithContext reseeds them
+// from the seed the replacing context carries, as if it were given as Context.
+type seeder interface {
+	reseed(ctx context.Context)
+}
+
+// resetter is implemented by calculators that grow intervals from internal state.
+type resetter interface {
+	// reset makes the next interval start over from the base interval.
+	reset()
+}
+
 // Next returns true if the next retry should be performed
 // and waits for the interval before the next retry.
 func (r *retrier) Next() bool {
-	defer func() {
+	r.turn.Lock()
+	defer r.turn.Unlock()
+	if r.chained != nil {
+		return r.chained()
+	}
+	return r.advance()
+}
+
+// Iter returns an iterator yielding the attempt number starting at 1,
+// e.g. for attempt := range r.Iter(). It waits between attempts and stops like Next.
+func (r *retrier) Iter() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		defer r.end()
+		for r.Next() {
+			if !yield(r.Attempts()) {
+				return
+			}
+		}
+	}
+}
+
+// advance is Next without middlewares.
+func (r *retrier) advance() bool {
+	r.mu.Lock()
+	gaveUp := r.gaveUp
+	r.mu.Unlock()
+	if gaveUp {
+		// Stay exhausted until Reset.
+		return false
+	}
+	ok := r.next()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ok {
+		r.logAttempt()
+		r.emit()
 		r.attempts++
-	}()
+	} else {
+		r.giveUp()
+	}
+	return ok
+}
+
+// next waits for the next attempt, holding mu only while it reads and updates the state.
+func (r *retrier) next() bool {
+	r.mu.Lock()
+	r.initContext()
+	if r.attempts == 0 {
+		r.mu.Unlock()
+		return r.first()
+	}
+	start := r.timeNow()
+	reason, stop := r.refuse(start)
+	if !stop {
+		r.mu.Unlock()
+		reason, stop = r.admit()
+		r.mu.Lock()
+	}
+	if stop {
+		r.stop(reason)
+		r.mu.Unlock()
+		return false
+	}
+	d := r.wait(start)
+	r.publish(d)
+	ctx, attempts, err := r.ctx, r.attempts, r.err
+	r.mu.Unlock()
+
+	if r.onRetry != nil {
+		r.onRetry(attempts+1, d)
+	}
+	if r.notify != nil && err != nil {
+		r.notify(err, attempts, d)
+	}
+	sleepErr := r.sleep(ctx, d)
+	ok := sleepErr == nil && globalLimiter.wait(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.slept += r.since(start)
+	if sleepErr != nil {
+		return r.stopBySleep(ctx, sleepErr)
+	}
+	if !ok {
+		return r.stop(contextReason(ctx))
+	}
+	return true
+}
+
+// refuse reports whether no more retries should be performed at now and why.
+func (r *retrier) refuse(now time.Time) (StopReason, bool) {
+	if (r.keepGoing == nil || !r.byDo) && 0 < r.maxAttempts && r.maxAttempts <= r.attempts {
+		return MaxAttempts, true
+	}
+	if reason, ok := r.expired(now); ok {
+		return reason, true
+	}
+	return Running, false
+}
+
+// admit reports whether the guard or the breaker refuses the next retry and why.
+// It is called without holding mu, so that they can call the methods of the retrier.
+func (r *retrier) admit() (StopReason, bool) {
+	if !r.guard.AllowRetry() {
+		return Denied, true
+	}
+	if !r.allowAttempt() {
+		return BreakerOpen, true
+	}
+	return Running, false
+}
+
+// allowAttempt consults the breaker of the Do helpers if any before waiting for an attempt,
+// so that no time is spent waiting for an attempt it refuses.
+func (r *retrier) allowAttempt() bool {
+	if !r.byDo || r.breaker == nil {
+		return true
+	}
+	r.allowed = r.breaker.Allow()
+	return r.allowed
+}
+
+// first waits InitialDelay if any before the first attempt,
+// which counts as an attempt like any other against MaxAttempts.
+func (r *retrier) first() bool {
+	allowed := r.allowAttempt()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !allowed {
+		return r.stop(BreakerOpen)
+	}
+	if r.initialDelay > 0 {
+		start := r.timeNow()
+		r.lastInterval = r.untilDeadline(start, r.initialDelay)
+		ctx, d := r.ctx, r.lastInterval
+		r.mu.Unlock()
+		err := r.sleep(ctx, d)
+		r.mu.Lock()
+		r.slept += r.since(start)
+		if err != nil {
+			return r.stopBySleep(ctx, err)
+		}
+	}
+	r.started = r.timeNow()
+	r.deposit()
+	return true
+}
+
+// Wait sleeps for the next interval of the algorithm once without a loop,
+// e.g. between two explicit stages, and advances the algorithm.
+// It returns ctx.Err() if ctx is done first, or the error of the sleep function given by WithSleep.
+func (r *retrier) Wait(ctx context.Context) error {
+	r.mu.Lock()
+	start := r.timeNow()
+	d := r.wait(start)
+	r.mu.Unlock()
+	err := r.sleep(ctx, d)
+	r.mu.Lock()
+	r.slept += r.since(start)
+	r.mu.Unlock()
+	return err
+}
+
+// expired reports whether the context is done, now has reached the deadline
+// or MaxElapsedTime has elapsed, and why.
+// If more than one of the deadline of the context, Deadline and MaxElapsedTime have passed,
+// it reports the one that passed first, e.g. MaxElapsedTime during an attempt outliving the context.
+func (r *retrier) expired(now time.Time) (StopReason, bool) {
+	reason, at := Running, time.Time{}
+	passed := func(bound StopReason, t time.Time) {
+		if !now.Before(t) && (reason == Running || t.Before(at)) {
+			reason, at = bound, t
+		}
+	}
+	if !r.deadline.IsZero() {
+		passed(Deadline, r.deadline)
+	}
+	if r.maxElapsed > 0 {
+		passed(MaxElapsed, r.started.Add(r.maxElapsed))
+	}
+	if r.ctx.Err() == nil {
+		return reason, reason != Running
+	}
+	if ctxReason := contextReason(r.ctx); ctxReason != Timeout || reason == Running {
+		return ctxReason, true
+	}
+	if ctxDeadline, ok := r.ctx.Deadline(); !ok || !at.Before(ctxDeadline) {
+		return Timeout, true
+	}
+	return reason, true
+}
+
+// wait returns the duration to wait from now before the next retry
+// and records it as the latest interval.
+func (r *retrier) wait(now time.Time) time.Duration {
+	if r.skipWait {
+		r.skipWait = false
+		r.lastInterval = 0
+	} else {
+		r.lastInterval = r.untilDeadline(now, r.interval())
+	}
+	if r.stats != nil {
+		r.stats.record(r.lastInterval)
+	}
+	return r.lastInterval
+}
+
+// untilDeadline shortens d not to wait past the deadline or MaxElapsedTime if any.
+// The context needs no care since waiting ends as soon as it is done.
+func (r *retrier) untilDeadline(now time.Time, d time.Duration) time.Duration {
+	if !r.deadline.IsZero() {
+		d = min(d, r.deadline.Sub(now))
+	}
+	if r.maxElapsed > 0 && r.attempts > 0 {
+		d = min(d, r.maxElapsed-now.Sub(r.started))
+	}
+	return max(d, 0)
+}
+
+// interval returns the duration to wait before the next retry.
+func (r *retrier) interval() time.Duration {
+	d := r.calc()
+	if r.overrideDelay {
+		r.overrideDelay = false
+		d = r.nextDelay
+		if c, ok := r.calculator.(capper); ok && c.maxInterval() < d {
+			d = c.maxInterval()
+		}
+		return d
+	}
+	if r.timeScale != nil {
+		d = time.Duration(float64(d) * r.timeScale(r.timeNow()))
+	}
+	return r.rounding.round(d, r.grid)
+}
+
+// WithTimeScale multiplies every interval by scale of the current time,
+// e.g. to back off harder during business hours by returning 3.0 and 1.0 otherwise.
+// scale is called while the retrier is locked, so it must not call the methods of the retrier.
+func WithTimeScale(scale func(now time.Time) float64) Option {
+	return func(r *retrier) {
+		r.timeScale = scale
+	}
+}
+
+// stop records why the retrier stopped and returns false for convenience.
+func (r *retrier) stop(reason StopReason) bool {
+	r.reason = reason
+	return false
+}
+
+// Schedule computes when the next attempt should occur and passes the time to fire
+// instead of waiting for it, so that an external scheduler can drive retries.
+// Call Fire when the time arrives to advance the retrier.
+// fire is not called if no more attempts should be performed.
+//
+// The time is the current time plus the interval when Schedule is called,
+// so it carries the monotonic clock reading of time.Now.
+// Wait for it with time.Until or Time.Sub, which use the monotonic clock,
+// then a wall-clock step, e.g. by NTP, neither shortens nor stretches the wait.
+func (r *retrier) Schedule(fire func(at time.Time)) {
+	r.mu.Lock()
+	now, d, ok := r.schedule()
+	r.mu.Unlock()
+	if ok {
+		fire(now.Add(d))
+	}
+}
+
+// schedule reports whether the next attempt should occur and how long to wait for it from now.
+func (r *retrier) schedule() (now time.Time, d time.Duration, ok bool) {
+	r.initContext()
+	now = r.timeNow()
+	if r.attempts == 0 {
+		return now, r.initialDelay, true
+	}
+	if reason, stop := r.refuse(now); stop {
+		r.stop(reason)
+		r.giveUp()
+		return now, 0, false
+	}
+	return now, r.wait(now), true
+}
+
+// NextDelay advances the retrier like Next without waiting, e.g. for an event loop that sleeps by itself.
+// It returns the duration to wait before the next attempt and whether the attempt should be performed,
+// which is false once MaxAttempts, Deadline or the context stops retrying.
+func (r *retrier) NextDelay() (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, d, ok := r.schedule()
+	if ok {
+		r.fire()
+	}
+	return d, ok
+}
+
+// giveUp notifies that the retrier refused an attempt for the first time.
+func (r *retrier) giveUp() {
+	if r.gaveUp {
+		return
+	}
+	r.gaveUp = true
+	r.logGiveUp()
+	r.closeEvents()
+}
+
+// Fire advances the retrier to the attempt scheduled by Schedule.
+func (r *retrier) Fire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fire()
+}
+
+func (r *retrier) fire() {
+	if r.attempts == 0 {
+		r.started = r.timeNow()
+		r.deposit()
+	}
+	r.logAttempt()
+	r.emit()
+	r.attempts++
+}
+
+// Progress reports that the operation made partial progress even if it failed,
+// e.g. some chunks of a resumable upload were sent.
+// The interval before the next retry starts over from the base interval
+// because progress indicates the dependency is responsive.
+// The Do helpers call it for an error wrapped by the Progress function.
+func (r *retrier) Progress() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.calculator.(resetter); ok {
+		c.reset()
+	}
+}
+
+// Reset makes the retrier start a fresh loop with the same configuration,
+// e.g. to reuse it for independent operations in a long-lived worker.
+// It clears the attempts, the internal state of the algorithm, the last error and the Stats,
+// and restarts the default timeout if it applies.
+func (r *retrier) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancelTimeout != nil {
+		r.cancelTimeout()
+		r.cancelTimeout = nil
+		r.ctx = nil
+	}
+	if c, ok := r.calculator.(resetter); ok {
+		c.reset()
+	}
+	r.attempts = 0
+	r.err = nil
+	r.slept = 0
+	r.reason = Running
+	r.lastInterval = 0
+	r.skipWait = false
+	r.overrideDelay = false
+	r.repeats = 0
+	r.gaveUp = false
+	r.sleepErr = nil
+	r.closeEvents()
+	r.events = nil
+	r.eventsClosed = false
+	if r.stats != nil {
+		r.stats = &intervalStats{}
+	}
+}
+
+// Success makes the backoff start over after a successful attempt without ending the loop,
+// e.g. for a long-lived reconnect loop, so that MaxAttempts and MaxElapsedTime bound
+// consecutive failures rather than the whole session. The next retry waits the first interval,
+// and the budget of MaxElapsedTime restarts, i.e. Elapsed counts from the call.
+// Unlike Reset, the default timeout keeps counting, so give a Context to such a loop.
+// Record a failed attempt by Failure.
+func (r *retrier) Success() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.calculator.(resetter); ok {
+		c.reset()
+	}
+	if r.attempts > 1 {
+		r.attempts = 1
+	}
+	r.started = r.timeNow()
+	r.err = nil
+	r.lastInterval = 0
+	r.skipWait = false
+	r.overrideDelay = false
+}
+
+// Failure records err of a failed attempt as the counterpart of Success,
+// so that the next call of Next advances the backoff and counts against MaxAttempts and MaxElapsedTime
+// until a later Success starts it over. It is equivalent to SetErr with a non-nil error.
+func (r *retrier) Failure(err error) {
+	r.SetErr(err)
+}
+
+// SkipTo makes the retrier continue as if n attempts have been made, e.g. to resume a durable workflow
+// persisting only the number of attempts after the process restarts. The next interval is the one
+// before attempt n+1, and n counts against MaxAttempts. MaxElapsedTime counts from the call.
+// It must be called before the loop starts. See State to checkpoint the exact state of the algorithm.
+func (r *retrier) SkipTo(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n < 1 {
+		return
+	}
+	if 0 < r.maxAttempts && r.maxAttempts < n {
+		n = r.maxAttempts
+	}
+	// Replay the intervals since an algorithm like Jitter grows from the previous one.
+	for i := 1; i < n; i++ {
+		r.lastInterval = r.calc()
+	}
+	r.attempts = n
+	r.started = r.timeNow()
+}
+
+// Clone returns a fresh retrier with the same algorithm and options, e.g. to configure once
+// and give every goroutine its own loop instead of sharing the progress of one.
+// A jittered algorithm draws a new seed unless Key is given, so that clones do not retry in lockstep.
+// Middlewares added by Chain are not copied.
+func (r *retrier) Clone() *retrier {
+	return New(r.algorithm, r.opts...)
+}
+
+// SetNextDelay makes the next retry wait exactly d instead of the interval of the algorithm,
+// e.g. the duration a server asked by a Retry-After header. d is capped by Max of the algorithm if any.
+// The algorithm still advances, so subsequent retries follow its intervals.
+func (r *retrier) SetNextDelay(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if d < 0 {
+		d = 0
+	}
+	r.nextDelay = d
+	r.overrideDelay = true
+}
+
+// SetErr records the error of the current attempt in a Next loop,
+// so that Err tells why the loop failed after it ends. Pass nil on success.
+func (r *retrier) SetErr(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.err = err
+}
+
+// Err returns the error last recorded by SetErr, or nil if none is recorded.
+func (r *retrier) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Attempts returns the number of attempts allowed by Next so far.
+func (r *retrier) Attempts() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.attempts
+}
+
+// Elapsed returns the duration since the first attempt, which MaxElapsedTime is measured against,
+// e.g. to report that the loop gave up after 7 attempts in 42s. It is zero before the first Next.
+func (r *retrier) Elapsed() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.attempts == 0 {
+		return 0
+	}
+	return r.since(r.started)
+}
+
+// timeNow returns the current time by the clock of the retrier.
+func (r *retrier) timeNow() time.Time {
+	return r.clockOrReal().Now()
+}
+
+// since returns the duration elapsed since t.
+// It never goes negative even if the wall clock steps backward
+// and t has no monotonic clock reading.
+func (r *retrier) since(t time.Time) time.Duration {
+	d := r.timeNow().Sub(t)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// WithoutTimeout disables the default timeout which prevents an infinite loop
+// when neither Context nor MaxAttempts is given, so that the loop retries forever.
+//
+// Use it with care: without Context and MaxAttempts, the retry loop never ends
+// unless the loop body breaks it, e.g. on success.
+// It is meant for long-lived daemon loops that manage their own termination.
+// A loop bound by a process-wide context needs no option: give it as Context,
+// which replaces the default timeout, so the loop retries until it is canceled.
+func WithoutTimeout() Option {
+	return func(r *retrier) {
+		r.noTimeout = true
+	}
+}
+
+// WithDefaultTimeout changes the default timeout which prevents an infinite loop
+// when nothing else bounds it, e.g. to give up sooner on a latency-sensitive path.
+// It is 1 minute unless given.
+func WithDefaultTimeout(d time.Duration) Option {
+	return func(r *retrier) {
+		r.defaultTimeout = d
+	}
+}
+
+func (r *retrier) initContext() {
 	if r.ctx == nil {
-		if r.maxAttempts == 0 {
+		r.ownContext = true
+		if r.maxAttempts == 0 && r.deadline.IsZero() && r.maxElapsed == 0 && !r.noTimeout {
 			// Set timeout to prevent infinite loop.
 			ctx, cancel := context.WithTimeout(
 				context.Background(),
-				defaultTimeoutDuration,
+				r.defaultTimeout+r.initialDelay,
 			)
 			r.ctx = ctx
+			r.cancelTimeout = cancel
 			go func() {
 				<-ctx.Done()
 				cancel()
 			}()
 		} else {
-			// Prefer max attempts over timeout.
+			// Prefer max attempts, deadline and max elapsed time over timeout, or the caller opted out of it.
+			// Context given by the caller is never replaced, so its deadline applies along with them.
 			r.ctx = context.Background()
 		}
 	}
-	if r.attempts == 0 {
-		return true
-	}
-	if r.attempts == r.maxAttempts {
-		return false
-	}
-	select {
-	case <-r.ctx.Done():
-		return false
-	case <-time.After(r.calc()):
-		return true
-	}
 }
 
-type algorithm interface {
+// Algorithm is implemented by the algorithms of this package, e.g. Jitter,
+// so that other packages can take one and pass it to New or the Do helpers.
+// Implement Backoff and pass it by Custom for an algorithm of your own.
+type Algorithm interface {
 	new() retrier
 }
 
+// Option configures optional behavior of a retrier.
+type Option func(*retrier)
+
 // New creates a new Retrier.
-func New(a algorithm) retrier {
-	return a.new()
+// It returns a pointer so that copies share the progress of the retry loop.
+//
+// A retrier is safe for concurrent use. Concurrent calls of Next are serialized,
+// so workers sharing a retrier share its attempts and take turns waiting.
+// The other methods never wait for Next, so middlewares, callbacks and other goroutines may call them.
+// Schedule and Fire drive the same loop as Next, so a loop uses either of them, not both.
+func New(a Algorithm, opts ...Option) *retrier {
+	r := a.new()
+	r.algorithm = a
+	r.opts = opts
+	r.guard = AlwaysAllow
+	r.defaultTimeout = defaultTimeoutDuration
+	for _, opt := range opts {
+		opt(&r)
+	}
+	return &r
 }
 
-// defining this as a global variable for testing.
-var defaultTimeoutDuration = time.Minute
+// NewChecked is New which also returns warnings about configuration that can never take effect,
+// e.g. MaxElapsedTime longer than the time left until the deadline of the context,
+// which stops the loop first. The retrier works as created by New regardless of the warnings.
+func NewChecked(a Algorithm, opts ...Option) (*retrier, []string) {
+	r := New(a, opts...)
+	var warnings []string
+	if r.maxElapsed > 0 && r.ctx != nil {
+		if d, ok := r.ctx.Deadline(); ok {
+			if left := d.Sub(r.timeNow()); left < r.maxElapsed {
+				warnings = append(warnings, fmt.Sprintf("retry: MaxElapsedTime %s exceeds the %s left until the deadline of the context", r.maxElapsed, left.Round(time.Millisecond)))
+			}
+		}
+	}
+	return r, warnings
+}
 
-// randomBetween returns a random float64 number between min and max.
-func randomBetween(min, max float64) float64 {
-	return rand.Float64()*(max-min) + min
+// defaultTimeoutDuration is the default timeout unless WithDefaultTimeout is given.
+const defaultTimeoutDuration = time.Minute
+
+// randomAt returns a random float64 number between min and max
+// which is determined only by seed and attempt, so that an interval can be computed
+// without the state of a retrier.
+func randomAt(seed int64, attempt int, min, max float64) float64 {
+	// splitmix64 of the seed advanced by attempt steps.
+	x := uint64(seed) + uint64(attempt)*0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+	return float64(x>>11)/(1<<53)*(max-min) + min
+}
+
+// jitterAt returns randomAt, or the midpoint of min and max if deterministic.
+func jitterAt(deterministic bool, seed int64, attempt int, min, max float64) float64 {
+	if deterministic {
+		return (min + max) / 2
+	}
+	return randomAt(seed, attempt, min, max)
+}
+
+// seedKey is the context key for the seed set by WithSeed.
+type seedKey struct{}
+
+// WithSeed returns a copy of ctx carrying seed, e.g. derived from a request ID.
+// Jittered algorithms given the context as Context draw intervals from a source seeded by it,
+// so the retry timing of a request is reproducible for debugging
+// while it varies across requests. Key and Rand take precedence over the seed.
+func WithSeed(ctx context.Context, seed int64) context.Context {
+	return context.WithValue(ctx, seedKey{}, seed)
+}
+
+// newSeed returns the hash of key, a seed drawn from rnd or the seed in ctx in this order,
+// or a random seed from the global source if none is given.
+func newSeed(ctx context.Context, key string, rnd *rand.Rand) int64 {
+	if key != "" {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		return int64(h.Sum64())
+	}
+	if rnd != nil {
+		return rnd.Int63()
+	}
+	if ctx != nil {
+		if seed, ok := ctx.Value(seedKey{}).(int64); ok {
+			return seed
+		}
+	}
+	return rand.Int63()
 }
 
 // Jitter provides options for jitter intervals.
@@ -87,9 +775,9 @@ func randomBetween(min, max float64) float64 {
 // Retry #1:  2.20932s
 // Retry #2:  6.293147s
 // Retry #3:  12.881962s
-// Retry #4:  17.478132s
-// Retry #5:  22.84098s
-// Retry #6:  47.376313s
+// Retry #4:  15s
+// Retry #5:  15s
+// Retry #6:  15s
 // Retry #7:  10.263282s
 // Retry #8:  5.662684s
 // Retry #9:  2.550353s
@@ -97,67 +785,282 @@ func randomBetween(min, max float64) float64 {
 type Jitter struct {
 	// Context is for timeout or canceling retry loop. Default is 1 minute timeout.
 	Context context.Context
-	// Base is the base wait duration to retry. Default is 1 second.
+	// Deadline is the time after which no retry is performed. Default is no deadline.
+	// The interval before the last retry is shortened not to sleep past it.
+	// It also bounds the context DoContext passes unless Context is given, which is passed as is.
+	Deadline time.Time
+	// MaxElapsedTime stops retrying once it has elapsed since the first attempt.
+	// Default is 0, no limit. The interval before the last retry is shortened not to exceed it.
+	MaxElapsedTime time.Duration
+	// InitialDelay is the wait duration before the first attempt,
+	// e.g. to stagger startup across a fleet. Default is 0, no wait.
+	// It extends the default timeout rather than consuming it.
+	InitialDelay time.Duration
+	// Base is the base wait duration to retry. Default is 1 second. It is capped by Max.
 	Base time.Duration
 	// Max is the maximum wait duration to retry. Default is 15 seconds.
 	Max time.Duration
 	// MaxAttempts is the maximum number of retries. Default is 0.
-	// If set 0, it will prioritize timeout.
-	MaxAttempts float64
+	// If set 0, it will prioritize timeout. Otherwise it disables only the default timeout,
+	// and whichever of MaxAttempts and Context comes first stops retrying.
+	MaxAttempts int
+	// Key seeds the random intervals so that retries for the same key follow the same schedule
+	// while retries for different keys are decorrelated. Default is a random schedule.
+	Key string
+	// Rand is the source of the random intervals unless Key is given,
+	// e.g. a seeded source to assert exact sequences in tests. Default is the global source.
+	Rand *rand.Rand
+	// Deterministic takes the midpoint of every random range instead of drawing from it,
+	// e.g. for integration tests asserting timing. Default is false.
+	Deterministic bool
 
+	attempt  int
 	interval time.Duration
+	seed     int64
 }
 
 func (j *Jitter) calc() time.Duration {
-	if j.interval == 0 {
-		j.interval = j.Base
+	j.attempt++
+	j.interval = j.step(j.interval, j.attempt, j.seed)
+	return j.interval
+}
+
+// step returns the interval before the given retry following the interval prev.
+func (j *Jitter) step(prev time.Duration, attempt int, seed int64) time.Duration {
+	if prev == 0 {
+		prev = j.Base
 	}
-	d := time.Duration(math.Min(
+	return time.Duration(math.Min(
 		float64(j.Max),
-		randomBetween(float64(j.Base), float64(j.interval)*3),
+		jitterAt(j.Deterministic, seed, attempt, float64(j.Base), float64(prev)*3),
 	))
-	j.interval = d
+}
+
+// IntervalAt returns the interval before the given retry, which starts at 1,
+// as a pure function of the options, attempt and seed.
+// Since every interval depends on the previous one, it replays the intervals before attempt.
+func (j Jitter) IntervalAt(attempt int, seed int64) time.Duration {
+	j = j.withDefaults()
+	var d time.Duration
+	for i := 1; i <= attempt; i++ {
+		d = j.step(d, i, seed)
+	}
 	return d
 }
 
-func (j Jitter) new() retrier {
+func (j *Jitter) reset() {
+	j.attempt = 0
+	j.interval = 0
+}
+
+func (j *Jitter) maxInterval() time.Duration {
+	return j.Max
+}
+
+func (j *Jitter) setMax(max time.Duration) {
+	j.Max = max
+	*j = j.withDefaults()
+}
+
+func (j Jitter) withDefaults() Jitter {
 	if j.Base == 0 {
 		j.Base = time.Second
 	}
 	if j.Max == 0 {
-		j.Max = time.Minute
+		j.Max = 15 * time.Second
+	}
+	if j.Max < j.Base {
+		j.Base = j.Max
+	}
+	return j
+}
+
+func (j *Jitter) reseed(ctx context.Context) {
+	if j.Rand == nil {
+		j.seed = newSeed(ctx, j.Key, nil)
 	}
+}
+
+func (j Jitter) new() retrier {
+	j = j.withDefaults()
+	j.seed = newSeed(j.Context, j.Key, j.Rand)
 	return retrier{
-		calculator:  &j,
-		ctx:         j.Context,
-		maxAttempts: j.MaxAttempts,
+		calculator:   &j,
+		ctx:          j.Context,
+		deadline:     j.Deadline,
+		maxElapsed:   j.MaxElapsedTime,
+		initialDelay: j.InitialDelay,
+		maxAttempts:  j.MaxAttempts,
 	}
 }
 
 // Constant provides options for constant intervals.
 // You can set empty for any fields, it will use default values.
+//
+// Constant{} has no Max since the interval is fixed, so it relies on the default timeout
+// unless MaxAttempts, Context, Deadline or MaxElapsedTime bounds it.
+// It retries every second for 1 minute, which is 60 attempts.
 type Constant struct {
 	// Context is for timeout or canceling retry loop. Default is 1 minute timeout.
 	Context context.Context
+	// Deadline is the time after which no retry is performed. Default is no deadline.
+	// The interval before the last retry is shortened not to sleep past it.
+	// It also bounds the context DoContext passes unless Context is given, which is passed as is.
+	Deadline time.Time
+	// MaxElapsedTime stops retrying once it has elapsed since the first attempt.
+	// Default is 0, no limit. The interval before the last retry is shortened not to exceed it.
+	MaxElapsedTime time.Duration
+	// InitialDelay is the wait duration before the first attempt,
+	// e.g. to stagger startup across a fleet. Default is 0, no wait.
+	// It extends the default timeout rather than consuming it.
+	InitialDelay time.Duration
 	// Interval is the interval between retries. Default is 1 second.
 	Interval time.Duration
 	// MaxAttempts is the maximum number of retries. Default is 0.
-	// If set 0, it will prioritize timeout.
-	MaxAttempts float64
+	// If set 0, it will prioritize timeout. Otherwise it disables only the default timeout,
+	// and whichever of MaxAttempts and Context comes first stops retrying.
+	MaxAttempts int
 }
 
 func (c Constant) calc() time.Duration {
 	return c.Interval
 }
 
+// IntervalAt returns the interval before the given retry, which is always Interval.
+func (c Constant) IntervalAt(attempt int, seed int64) time.Duration {
+	return c.withDefaults().Interval
+}
+
+func (c Constant) withDefaults() Constant {
+	if c.Interval == 0 {
+		c.Interval = time.Second
+	}
+	return c
+}
+
 func (c Constant) new() retrier {
+	c = c.withDefaults()
+	return retrier{
+		calculator:   c,
+		ctx:          c.Context,
+		deadline:     c.Deadline,
+		maxElapsed:   c.MaxElapsedTime,
+		initialDelay: c.InitialDelay,
+		maxAttempts:  c.MaxAttempts,
+	}
+}
+
+// JitterMode controls how ConstantJitter spreads intervals around the nominal interval.
+type JitterMode int
+
+const (
+	// Symmetric draws intervals from [Interval - Jitter, Interval + Jitter].
+	Symmetric JitterMode = iota
+	// PositiveOnly draws intervals from [Interval, Interval + Jitter],
+	// so an interval never dips below the nominal one.
+	PositiveOnly
+)
+
+// ConstantJitter provides options for constant intervals with random jitter.
+// You can set empty for any fields, it will use default values.
+//
+// An interval can be computed by this expression.
+//
+// Symmetric:    interval = randomBetween(max(0, Interval - Jitter), Interval + Jitter)
+// PositiveOnly: interval = randomBetween(Interval, Interval + Jitter)
+type ConstantJitter struct {
+	// Context is for timeout or canceling retry loop. Default is 1 minute timeout.
+	Context context.Context
+	// Deadline is the time after which no retry is performed. Default is no deadline.
+	// The interval before the last retry is shortened not to sleep past it.
+	// It also bounds the context DoContext passes unless Context is given, which is passed as is.
+	Deadline time.Time
+	// MaxElapsedTime stops retrying once it has elapsed since the first attempt.
+	// Default is 0, no limit. The interval before the last retry is shortened not to exceed it.
+	MaxElapsedTime time.Duration
+	// InitialDelay is the wait duration before the first attempt,
+	// e.g. to stagger startup across a fleet. Default is 0, no wait.
+	// It extends the default timeout rather than consuming it.
+	InitialDelay time.Duration
+	// Interval is the nominal interval between retries. Default is 1 second.
+	Interval time.Duration
+	// Jitter is the maximum deviation from Interval. Default is Fraction of Interval.
+	Jitter time.Duration
+	// Fraction is the maximum deviation relative to Interval unless Jitter is given,
+	// e.g. 0.1 for Interval ± 10%. Default is 0.5.
+	Fraction float64
+	// Mode is the distribution of jitter around Interval. Default is Symmetric.
+	Mode JitterMode
+	// MaxAttempts is the maximum number of retries. Default is 0.
+	// If set 0, it will prioritize timeout. Otherwise it disables only the default timeout,
+	// and whichever of MaxAttempts and Context comes first stops retrying.
+	MaxAttempts int
+	// Key seeds the random intervals so that retries for the same key follow the same schedule
+	// while retries for different keys are decorrelated. Default is a random schedule.
+	Key string
+	// Rand is the source of the random intervals unless Key is given,
+	// e.g. a seeded source to assert exact sequences in tests. Default is the global source.
+	Rand *rand.Rand
+	// Deterministic takes the midpoint of every random range instead of drawing from it,
+	// e.g. for integration tests asserting timing. Default is false.
+	Deterministic bool
+
+	attempt int
+	seed    int64
+}
+
+func (c *ConstantJitter) calc() time.Duration {
+	c.attempt++
+	return c.IntervalAt(c.attempt, c.seed)
+}
+
+// IntervalAt returns the interval before the given retry, which starts at 1,
+// as a pure function of the options, attempt and seed.
+func (c ConstantJitter) IntervalAt(attempt int, seed int64) time.Duration {
+	c = c.withDefaults()
+	min := c.Interval
+	if c.Mode == Symmetric {
+		min = c.Interval - c.Jitter
+		if min < 0 {
+			min = 0
+		}
+	}
+	return time.Duration(jitterAt(c.Deterministic, seed, attempt, float64(min), float64(c.Interval+c.Jitter)))
+}
+
+func (c *ConstantJitter) reset() {
+	c.attempt = 0
+}
+
+func (c ConstantJitter) withDefaults() ConstantJitter {
 	if c.Interval == 0 {
 		c.Interval = time.Second
 	}
+	if c.Fraction == 0 {
+		c.Fraction = 0.5
+	}
+	if c.Jitter == 0 {
+		c.Jitter = time.Duration(float64(c.Interval) * c.Fraction)
+	}
+	return c
+}
+
+func (c *ConstantJitter) reseed(ctx context.Context) {
+	if c.Rand == nil {
+		c.seed = newSeed(ctx, c.Key, nil)
+	}
+}
+
+func (c ConstantJitter) new() retrier {
+	c = c.withDefaults()
+	c.seed = newSeed(c.Context, c.Key, c.Rand)
 	return retrier{
-		calculator:  c,
-		ctx:         c.Context,
-		maxAttempts: c.MaxAttempts,
+		calculator:   &c,
+		ctx:          c.Context,
+		deadline:     c.Deadline,
+		maxElapsed:   c.MaxElapsedTime,
+		initialDelay: c.InitialDelay,
+		maxAttempts:  c.MaxAttempts,
 	}
 }
 
@@ -166,8 +1069,11 @@ func (c Constant) new() retrier {
 //
 // An interval can be computed by this expression.
 //
-// temp = base * (2 ^ attempts)
-// interval = min(max, randomBetween(temp / 2, temp))
+// temp = base * (2 ^ min(attempts, maxDoublings))
+// interval = min(max, max(min, randomBetween(temp / 2, temp)))
+//
+// Since attempts starts at 1, the first interval is between base and base * 2.
+// Set StartAtBase to start the exponent at 0 instead.
 //
 // Example: Given 1 second for Base, 2 minutes for Max and 10 for MaxAttempts
 // the sequence 10 retries will be:
@@ -185,37 +1091,750 @@ func (c Constant) new() retrier {
 type ExponentialBackoff struct {
 	// Context is for timeout or canceling retry loop. Default is 1 minute timeout.
 	Context context.Context
+	// Deadline is the time after which no retry is performed. Default is no deadline.
+	// The interval before the last retry is shortened not to sleep past it.
+	// It also bounds the context DoContext passes unless Context is given, which is passed as is.
+	Deadline time.Time
+	// MaxElapsedTime stops retrying once it has elapsed since the first attempt.
+	// Default is 0, no limit. The interval before the last retry is shortened not to exceed it.
+	MaxElapsedTime time.Duration
+	// InitialDelay is the wait duration before the first attempt,
+	// e.g. to stagger startup across a fleet. Default is 0, no wait.
+	// It extends the default timeout rather than consuming it.
+	InitialDelay time.Duration
 	// Base controls the rate of exponential backoff interval growth.
-	// Default is 1 second.
+	// Default is 1 second. It is capped by Max.
 	Base time.Duration
 	// Max is the maximum wait duration to retry. Default is 15 seconds.
 	Max time.Duration
+	// Min is the minimum wait duration to retry regardless of jitter,
+	// so that early retries never hammer the server. Default is Base, or Base / 2 if StartAtBase is set.
+	Min time.Duration
+	// MaxDoublings caps the exponent, so that intervals stop growing at base * (2 ^ MaxDoublings)
+	// while they are still jittered, like maxDoublings of Google Cloud. Default is 0, no cap.
+	MaxDoublings int
 	// MaxAttempts is the maximum number of retries. Default is 0.
-	// If set 0, it will prioritize timeout.
-	MaxAttempts float64
+	// If set 0, it will prioritize timeout. Otherwise it disables only the default timeout,
+	// and whichever of MaxAttempts and Context comes first stops retrying.
+	MaxAttempts int
+	// Key seeds the random intervals so that retries for the same key follow the same schedule
+	// while retries for different keys are decorrelated. Default is a random schedule.
+	Key string
+	// Rand is the source of the random intervals unless Key is given,
+	// e.g. a seeded source to assert exact sequences in tests. Default is the global source.
+	Rand *rand.Rand
+	// Deterministic takes the midpoint of every random range instead of drawing from it,
+	// e.g. for integration tests asserting timing. Default is false.
+	Deterministic bool
+	// PhaseJitter shifts the whole curve by a random fractional exponent in [0, 1)
+	// drawn once per retrier, e.g. 1.3s, 2.6s, 5.2s instead of 1s, 2s, 4s,
+	// so that clients starting at the same time stay apart. Default is false.
+	PhaseJitter bool
+	// StartAtBase starts the exponent at 0 instead of 1, so that the first retry waits
+	// between Base / 2 and Base rather than between Base and Base * 2. Default is false.
+	StartAtBase bool
 
-	attempt float64
+	attempt int
+	seed    int64
 }
 
 func (b *ExponentialBackoff) calc() time.Duration {
 	b.attempt++
-	temp := float64(b.Base) * math.Pow(2, b.attempt)
+	return b.IntervalAt(b.attempt, b.seed)
+}
+
+// IntervalAt returns the interval before the given retry, which starts at 1,
+// as a pure function of the options, attempt and seed.
+func (b ExponentialBackoff) IntervalAt(attempt int, seed int64) time.Duration {
+	b = b.withDefaults()
+	exp := attempt
+	if b.StartAtBase {
+		exp--
+	}
+	if b.MaxDoublings > 0 && b.MaxDoublings < exp {
+		exp = b.MaxDoublings
+	}
+	temp := float64(b.Base) * math.Pow(2, float64(exp)+b.phase(seed))
+	if float64(b.Max) <= temp/2 {
+		// Every draw exceeds Max. It also keeps an overflowed temp away from the draw.
+		return b.Max
+	}
 	return time.Duration(math.Min(
 		float64(b.Max),
-		randomBetween(temp/2, temp),
+		math.Max(float64(b.Min), jitterAt(b.Deterministic, seed, attempt, temp/2, temp)),
 	))
 }
 
-func (b ExponentialBackoff) new() retrier {
+// phase returns the fractional exponent the curve is shifted by.
+func (b ExponentialBackoff) phase(seed int64) float64 {
+	if !b.PhaseJitter {
+		return 0
+	}
+	// Attempts start at 1, so 0 is free for drawing the phase.
+	return jitterAt(b.Deterministic, seed, 0, 0, 1)
+}
+
+func (b *ExponentialBackoff) reset() {
+	b.attempt = 0
+}
+
+func (b *ExponentialBackoff) maxInterval() time.Duration {
+	return b.Max
+}
+
+func (b *ExponentialBackoff) setMax(max time.Duration) {
+	b.Max = max
+	*b = b.withDefaults()
+}
+
+func (b ExponentialBackoff) withDefaults() ExponentialBackoff {
 	if b.Base == 0 {
 		b.Base = time.Second
 	}
 	if b.Max == 0 {
 		b.Max = 15 * time.Second
 	}
+	if b.Max < b.Base {
+		b.Base = b.Max
+	}
+	if b.Min == 0 {
+		b.Min = b.Base
+		if b.StartAtBase {
+			// The first retry is drawn between Base / 2 and Base, which Base as Min would flatten.
+			b.Min = b.Base / 2
+		}
+	}
+	return b
+}
+
+func (b *ExponentialBackoff) reseed(ctx context.Context) {
+	if b.Rand == nil {
+		b.seed = newSeed(ctx, b.Key, nil)
+	}
+}
+
+func (b ExponentialBackoff) new() retrier {
+	b = b.withDefaults()
+	b.seed = newSeed(b.Context, b.Key, b.Rand)
+	return retrier{
+		calculator:   &b,
+		ctx:          b.Context,
+		deadline:     b.Deadline,
+		maxElapsed:   b.MaxElapsedTime,
+		initialDelay: b.InitialDelay,
+		maxAttempts:  b.MaxAttempts,
+	}
+}
+
+// Linear provides options for linearly growing intervals.
+// You can set empty for any fields, it will use default values.
+//
+// An interval can be computed by this expression.
+//
+// interval = min(max, base + increment * (attempts - 1))
+//
+// Example: Given 1 second for Base, 2 seconds for Increment and 6 seconds for Max
+// the sequence of retries will be:
+//
+// Retry #1: 1s
+// Retry #2: 3s
+// Retry #3: 5s
+// Retry #4: 6s
+// Retry #5: 6s
+type Linear struct {
+	// Context is for timeout or canceling retry loop. Default is 1 minute timeout.
+	Context context.Context
+	// Deadline is the time after which no retry is performed. Default is no deadline.
+	// The interval before the last retry is shortened not to sleep past it.
+	// It also bounds the context DoContext passes unless Context is given, which is passed as is.
+	Deadline time.Time
+	// MaxElapsedTime stops retrying once it has elapsed since the first attempt.
+	// Default is 0, no limit. The interval before the last retry is shortened not to exceed it.
+	MaxElapsedTime time.Duration
+	// InitialDelay is the wait duration before the first attempt,
+	// e.g. to stagger startup across a fleet. Default is 0, no wait.
+	// It extends the default timeout rather than consuming it.
+	InitialDelay time.Duration
+	// Base is the wait duration before the first retry. Default is 1 second. It is capped by Max.
+	Base time.Duration
+	// Increment is added to the interval on every retry. Default is Base.
+	Increment time.Duration
+	// Max is the maximum wait duration to retry. Default is 15 seconds.
+	Max time.Duration
+	// MaxAttempts is the maximum number of retries. Default is 0.
+	// If set 0, it will prioritize timeout. Otherwise it disables only the default timeout,
+	// and whichever of MaxAttempts and Context comes first stops retrying.
+	MaxAttempts int
+
+	attempt int
+}
+
+func (l *Linear) calc() time.Duration {
+	l.attempt++
+	return l.IntervalAt(l.attempt, 0)
+}
+
+// IntervalAt returns the interval before the given retry, which starts at 1.
+// The seed is ignored since the intervals are not random.
+func (l Linear) IntervalAt(attempt int, seed int64) time.Duration {
+	l = l.withDefaults()
+	return time.Duration(math.Min(
+		float64(l.Max),
+		float64(l.Base)+float64(l.Increment)*float64(attempt-1),
+	))
+}
+
+func (l *Linear) reset() {
+	l.attempt = 0
+}
+
+func (l *Linear) maxInterval() time.Duration {
+	return l.Max
+}
+
+func (l *Linear) setMax(max time.Duration) {
+	l.Max = max
+	*l = l.withDefaults()
+}
+
+func (l Linear) withDefaults() Linear {
+	if l.Base == 0 {
+		l.Base = time.Second
+	}
+	if l.Increment == 0 {
+		l.Increment = l.Base
+	}
+	if l.Max == 0 {
+		l.Max = 15 * time.Second
+	}
+	if l.Max < l.Base {
+		l.Base = l.Max
+	}
+	return l
+}
+
+func (l Linear) new() retrier {
+	l = l.withDefaults()
 	return retrier{
-		calculator:  &b,
-		ctx:         b.Context,
-		maxAttempts: b.MaxAttempts,
+		calculator:   &l,
+		ctx:          l.Context,
+		deadline:     l.Deadline,
+		maxElapsed:   l.MaxElapsedTime,
+		initialDelay: l.InitialDelay,
+		maxAttempts:  l.MaxAttempts,
 	}
 }
+
+// LinearJitter provides options for linearly growing intervals with random jitter,
+// which gives a predictable ramp while preventing a fleet of clients from retrying in lockstep.
+// You can set empty for any fields, it will use default values.
+//
+// An interval can be computed by this expression.
+//
+// nominal = base + increment * (attempts - 1)
+// interval = min(max, randomBetween(nominal - spread, nominal + spread))
+type LinearJitter struct {
+	// Context is for timeout or canceling retry loop. Default is 1 minute timeout.
+	Context context.Context
+	// Deadline is the time after which no retry is performed. Default is no deadline.
+	// The interval before the last retry is shortened not to sleep past it.
+	// It also bounds the context DoContext passes unless Context is given, which is passed as is.
+	Deadline time.Time
+	// MaxElapsedTime stops retrying once it has elapsed since the first attempt.
+	// Default is 0, no limit. The interval before the last retry is shortened not to exceed it.
+	MaxElapsedTime time.Duration
+	// InitialDelay is the wait duration before the first attempt,
+	// e.g. to stagger startup across a fleet. Default is 0, no wait.
+	// It extends the default timeout rather than consuming it.
+	InitialDelay time.Duration
+	// Base is the nominal wait duration before the first retry. Default is 1 second. It is capped by Max.
+	Base time.Duration
+	// Increment is added to the nominal interval on every retry. Default is Base.
+	Increment time.Duration
+	// Spread is the maximum deviation of an interval from the nominal one. Default is half of Base.
+	// Intervals never go below zero.
+	Spread time.Duration
+	// Max is the maximum wait duration to retry. Default is 15 seconds.
+	Max time.Duration
+	// MaxAttempts is the maximum number of retries. Default is 0.
+	// If set 0, it will prioritize timeout. Otherwise it disables only the default timeout,
+	// and whichever of MaxAttempts and Context comes first stops retrying.
+	MaxAttempts int
+	// Key seeds the random intervals so that retries for the same key follow the same schedule
+	// while retries for different keys are decorrelated. Default is a random schedule.
+	Key string
+	// Rand is the source of the random intervals unless Key is given,
+	// e.g. a seeded source to assert exact sequences in tests. Default is the global source.
+	Rand *rand.Rand
+	// Deterministic takes the midpoint of every random range instead of drawing from it,
+	// e.g. for integration tests asserting timing. Default is false.
+	Deterministic bool
+
+	attempt int
+	seed    int64
+}
+
+func (l *LinearJitter) calc() time.Duration {
+	l.attempt++
+	return l.IntervalAt(l.attempt, l.seed)
+}
+
+// IntervalAt returns the interval before the given retry, which starts at 1,
+// as a pure function of the options, attempt and seed.
+func (l LinearJitter) IntervalAt(attempt int, seed int64) time.Duration {
+	l = l.withDefaults()
+	nominal := float64(l.Base) + float64(l.Increment)*float64(attempt-1)
+	d := jitterAt(l.Deterministic, seed, attempt, nominal-float64(l.Spread), nominal+float64(l.Spread))
+	return time.Duration(math.Max(0, math.Min(float64(l.Max), d)))
+}
+
+func (l *LinearJitter) reset() {
+	l.attempt = 0
+}
+
+func (l *LinearJitter) maxInterval() time.Duration {
+	return l.Max
+}
+
+func (l *LinearJitter) setMax(max time.Duration) {
+	l.Max = max
+	*l = l.withDefaults()
+}
+
+func (l LinearJitter) withDefaults() LinearJitter {
+	if l.Base == 0 {
+		l.Base = time.Second
+	}
+	if l.Increment == 0 {
+		l.Increment = l.Base
+	}
+	if l.Max == 0 {
+		l.Max = 15 * time.Second
+	}
+	if l.Max < l.Base {
+		l.Base = l.Max
+	}
+	if l.Spread == 0 {
+		l.Spread = l.Base / 2
+	}
+	return l
+}
+
+func (l *LinearJitter) reseed(ctx context.Context) {
+	if l.Rand == nil {
+		l.seed = newSeed(ctx, l.Key, nil)
+	}
+}
+
+func (l LinearJitter) new() retrier {
+	l = l.withDefaults()
+	l.seed = newSeed(l.Context, l.Key, l.Rand)
+	return retrier{
+		calculator:   &l,
+		ctx:          l.Context,
+		deadline:     l.Deadline,
+		maxElapsed:   l.MaxElapsedTime,
+		initialDelay: l.InitialDelay,
+		maxAttempts:  l.MaxAttempts,
+	}
+}
+
+// Fibonacci provides options for intervals growing along the Fibonacci sequence,
+// which grow more gently than exponential backoff.
+// You can set empty for any fields, it will use default values.
+//
+// An interval can be computed by this expression.
+//
+// interval = min(max, base * fib(attempts))
+//
+// Example: Given 1 second for Base and 15 seconds for Max
+// the sequence of retries will be:
+//
+// Retry #1: 1s
+// Retry #2: 1s
+// Retry #3: 2s
+// Retry #4: 3s
+// Retry #5: 5s
+// Retry #6: 8s
+// Retry #7: 13s
+// Retry #8: 15s
+type Fibonacci struct {
+	// Context is for timeout or canceling retry loop. Default is 1 minute timeout.
+	Context context.Context
+	// Deadline is the time after which no retry is performed. Default is no deadline.
+	// The interval before the last retry is shortened not to sleep past it.
+	// It also bounds the context DoContext passes unless Context is given, which is passed as is.
+	Deadline time.Time
+	// MaxElapsedTime stops retrying once it has elapsed since the first attempt.
+	// Default is 0, no limit. The interval before the last retry is shortened not to exceed it.
+	MaxElapsedTime time.Duration
+	// InitialDelay is the wait duration before the first attempt,
+	// e.g. to stagger startup across a fleet. Default is 0, no wait.
+	// It extends the default timeout rather than consuming it.
+	InitialDelay time.Duration
+	// Base is the wait duration before the first retry. Default is 1 second. It is capped by Max.
+	Base time.Duration
+	// Max is the maximum wait duration to retry. Default is 15 seconds.
+	Max time.Duration
+	// MaxAttempts is the maximum number of retries. Default is 0.
+	// If set 0, it will prioritize timeout. Otherwise it disables only the default timeout,
+	// and whichever of MaxAttempts and Context comes first stops retrying.
+	MaxAttempts int
+
+	// prev and cur are the running pair of the Fibonacci sequence.
+	prev, cur float64
+}
+
+func (f *Fibonacci) calc() time.Duration {
+	if f.cur == 0 {
+		f.prev, f.cur = 0, 1
+	} else {
+		f.prev, f.cur = f.cur, f.prev+f.cur
+	}
+	return time.Duration(math.Min(float64(f.Max), float64(f.Base)*f.cur))
+}
+
+// IntervalAt returns the interval before the given retry, which starts at 1.
+// The seed is ignored since the intervals are not random.
+func (f Fibonacci) IntervalAt(attempt int, seed int64) time.Duration {
+	f = f.withDefaults()
+	f.reset()
+	var d time.Duration
+	for i := 0; i < attempt; i++ {
+		d = f.calc()
+	}
+	return d
+}
+
+func (f *Fibonacci) reset() {
+	f.prev, f.cur = 0, 0
+}
+
+func (f *Fibonacci) maxInterval() time.Duration {
+	return f.Max
+}
+
+func (f *Fibonacci) setMax(max time.Duration) {
+	f.Max = max
+	*f = f.withDefaults()
+}
+
+func (f Fibonacci) withDefaults() Fibonacci {
+	if f.Base == 0 {
+		f.Base = time.Second
+	}
+	if f.Max == 0 {
+		f.Max = 15 * time.Second
+	}
+	if f.Max < f.Base {
+		f.Base = f.Max
+	}
+	return f
+}
+
+func (f Fibonacci) new() retrier {
+	f = f.withDefaults()
+	return retrier{
+		calculator:   &f,
+		ctx:          f.Context,
+		deadline:     f.Deadline,
+		maxElapsed:   f.MaxElapsedTime,
+		initialDelay: f.InitialDelay,
+		maxAttempts:  f.MaxAttempts,
+	}
+}
+
+// DecorrelatedJitter is Jitter by the name of the "Decorrelated Jitter" algorithm
+// of the AWS Architecture Blog, whose formula Jitter implements exactly,
+// so that intervals are comparable to the AWS SDKs of other languages.
+type DecorrelatedJitter = Jitter
+
+// FullJitter provides options for the "Full Jitter" algorithm of the AWS Architecture Blog,
+// which spreads intervals from zero up to the exponential ceiling to avoid thundering herds.
+// You can set empty for any fields, it will use default values.
+//
+// An interval can be computed by this expression.
+//
+// ceiling = min(max, base * (2 ^ attempts))
+// interval = randomBetween(0, ceiling)
+type FullJitter struct {
+	// Context is for timeout or canceling retry loop. Default is 1 minute timeout.
+	Context context.Context
+	// Deadline is the time after which no retry is performed. Default is no deadline.
+	// The interval before the last retry is shortened not to sleep past it.
+	// It also bounds the context DoContext passes unless Context is given, which is passed as is.
+	Deadline time.Time
+	// MaxElapsedTime stops retrying once it has elapsed since the first attempt.
+	// Default is 0, no limit. The interval before the last retry is shortened not to exceed it.
+	MaxElapsedTime time.Duration
+	// InitialDelay is the wait duration before the first attempt,
+	// e.g. to stagger startup across a fleet. Default is 0, no wait.
+	// It extends the default timeout rather than consuming it.
+	InitialDelay time.Duration
+	// Base controls the rate of the ceiling growth. Default is 1 second. It is capped by Max.
+	Base time.Duration
+	// Max is the maximum wait duration to retry. Default is 15 seconds.
+	Max time.Duration
+	// MaxAttempts is the maximum number of retries. Default is 0.
+	// If set 0, it will prioritize timeout. Otherwise it disables only the default timeout,
+	// and whichever of MaxAttempts and Context comes first stops retrying.
+	MaxAttempts int
+	// Key seeds the random intervals so that retries for the same key follow the same schedule
+	// while retries for different keys are decorrelated. Default is a random schedule.
+	Key string
+	// Rand is the source of the random intervals unless Key is given,
+	// e.g. a seeded source to assert exact sequences in tests. Default is the global source.
+	Rand *rand.Rand
+	// Deterministic takes the midpoint of every random range instead of drawing from it,
+	// e.g. for integration tests asserting timing. Default is false.
+	Deterministic bool
+
+	attempt int
+	seed    int64
+}
+
+func (f *FullJitter) calc() time.Duration {
+	f.attempt++
+	return f.IntervalAt(f.attempt, f.seed)
+}
+
+// IntervalAt returns the interval before the given retry, which starts at 1,
+// as a pure function of the options, attempt and seed.
+func (f FullJitter) IntervalAt(attempt int, seed int64) time.Duration {
+	f = f.withDefaults()
+	return time.Duration(jitterAt(f.Deterministic, seed, attempt, 0, exponentialCeiling(f.Base, f.Max, attempt)))
+}
+
+func (f *FullJitter) reset() {
+	f.attempt = 0
+}
+
+func (f *FullJitter) maxInterval() time.Duration {
+	return f.Max
+}
+
+func (f *FullJitter) setMax(max time.Duration) {
+	f.Max = max
+	*f = f.withDefaults()
+}
+
+func (f FullJitter) withDefaults() FullJitter {
+	if f.Base == 0 {
+		f.Base = time.Second
+	}
+	if f.Max == 0 {
+		f.Max = 15 * time.Second
+	}
+	if f.Max < f.Base {
+		f.Base = f.Max
+	}
+	return f
+}
+
+func (f *FullJitter) reseed(ctx context.Context) {
+	if f.Rand == nil {
+		f.seed = newSeed(ctx, f.Key, nil)
+	}
+}
+
+func (f FullJitter) new() retrier {
+	f = f.withDefaults()
+	f.seed = newSeed(f.Context, f.Key, f.Rand)
+	return retrier{
+		calculator:   &f,
+		ctx:          f.Context,
+		deadline:     f.Deadline,
+		maxElapsed:   f.MaxElapsedTime,
+		initialDelay: f.InitialDelay,
+		maxAttempts:  f.MaxAttempts,
+	}
+}
+
+// EqualJitter provides options for the "Equal Jitter" algorithm of the AWS Architecture Blog,
+// which keeps half of the exponential interval fixed and randomizes the other half
+// for smoother load.
+// You can set empty for any fields, it will use default values.
+//
+// An interval can be computed by this expression.
+//
+// temp = min(max, base * (2 ^ attempts))
+// interval = temp / 2 + randomBetween(0, temp / 2)
+type EqualJitter struct {
+	// Context is for timeout or canceling retry loop. Default is 1 minute timeout.
+	Context context.Context
+	// Deadline is the time after which no retry is performed. Default is no deadline.
+	// The interval before the last retry is shortened not to sleep past it.
+	// It also bounds the context DoContext passes unless Context is given, which is passed as is.
+	Deadline time.Time
+	// MaxElapsedTime stops retrying once it has elapsed since the first attempt.
+	// Default is 0, no limit. The interval before the last retry is shortened not to exceed it.
+	MaxElapsedTime time.Duration
+	// InitialDelay is the wait duration before the first attempt,
+	// e.g. to stagger startup across a fleet. Default is 0, no wait.
+	// It extends the default timeout rather than consuming it.
+	InitialDelay time.Duration
+	// Base controls the rate of exponential backoff interval growth. Default is 1 second. It is capped by Max.
+	Base time.Duration
+	// Max is the maximum wait duration to retry. Default is 15 seconds.
+	Max time.Duration
+	// MaxAttempts is the maximum number of retries. Default is 0.
+	// If set 0, it will prioritize timeout. Otherwise it disables only the default timeout,
+	// and whichever of MaxAttempts and Context comes first stops retrying.
+	MaxAttempts int
+	// Key seeds the random intervals so that retries for the same key follow the same schedule
+	// while retries for different keys are decorrelated. Default is a random schedule.
+	Key string
+	// Rand is the source of the random intervals unless Key is given,
+	// e.g. a seeded source to assert exact sequences in tests. Default is the global source.
+	Rand *rand.Rand
+	// Deterministic takes the midpoint of every random range instead of drawing from it,
+	// e.g. for integration tests asserting timing. Default is false.
+	Deterministic bool
+
+	attempt int
+	seed    int64
+}
+
+func (e *EqualJitter) calc() time.Duration {
+	e.attempt++
+	return e.IntervalAt(e.attempt, e.seed)
+}
+
+// IntervalAt returns the interval before the given retry, which starts at 1,
+// as a pure function of the options, attempt and seed.
+func (e EqualJitter) IntervalAt(attempt int, seed int64) time.Duration {
+	e = e.withDefaults()
+	half := exponentialCeiling(e.Base, e.Max, attempt) / 2
+	return time.Duration(half + jitterAt(e.Deterministic, seed, attempt, 0, half))
+}
+
+func (e *EqualJitter) reset() {
+	e.attempt = 0
+}
+
+func (e *EqualJitter) maxInterval() time.Duration {
+	return e.Max
+}
+
+func (e *EqualJitter) setMax(max time.Duration) {
+	e.Max = max
+	*e = e.withDefaults()
+}
+
+func (e EqualJitter) withDefaults() EqualJitter {
+	if e.Base == 0 {
+		e.Base = time.Second
+	}
+	if e.Max == 0 {
+		e.Max = 15 * time.Second
+	}
+	if e.Max < e.Base {
+		e.Base = e.Max
+	}
+	return e
+}
+
+func (e *EqualJitter) reseed(ctx context.Context) {
+	if e.Rand == nil {
+		e.seed = newSeed(ctx, e.Key, nil)
+	}
+}
+
+func (e EqualJitter) new() retrier {
+	e = e.withDefaults()
+	e.seed = newSeed(e.Context, e.Key, e.Rand)
+	return retrier{
+		calculator:   &e,
+		ctx:          e.Context,
+		deadline:     e.Deadline,
+		maxElapsed:   e.MaxElapsedTime,
+		initialDelay: e.InitialDelay,
+		maxAttempts:  e.MaxAttempts,
+	}
+}
+
+// Backoff computes intervals of a custom algorithm, e.g. a lookup table or a sequence from configuration.
+// Pass it to New or the Do helpers by Custom.
+// Calc is called while the retrier is locked, so it must not call the methods of the retrier.
+type Backoff interface {
+	// Calc returns the interval before the given retry, which starts at 1.
+	Calc(attempt int) time.Duration
+}
+
+// BackoffFunc is a function implementing Backoff.
+type BackoffFunc func(attempt int) time.Duration
+
+// Calc calls f(attempt).
+func (f BackoffFunc) Calc(attempt int) time.Duration {
+	return f(attempt)
+}
+
+// Custom provides options for a custom algorithm given by Backoff.
+// The intervals come from Backoff, while the retry loop is bounded by the same fields
+// as the algorithms of this package: the default timeout applies unless
+// MaxAttempts, Context, Deadline or MaxElapsedTime is given,
+// and WithContext, WithMaxAttempts and the other options work alike.
+// WithMax has no effect since Backoff is responsible for capping its intervals.
+//
+// Example: retry.New(retry.Custom{Backoff: retry.BackoffFunc(table), MaxAttempts: 5})
+type Custom struct {
+	// Backoff computes the intervals. It is required, so New panics if it is nil.
+	Backoff Backoff
+	// Context is for timeout or canceling retry loop. Default is 1 minute timeout.
+	Context context.Context
+	// Deadline is the time after which no retry is performed. Default is no deadline.
+	// The interval before the last retry is shortened not to sleep past it.
+	// It also bounds the context DoContext passes unless Context is given, which is passed as is.
+	Deadline time.Time
+	// MaxElapsedTime stops retrying once it has elapsed since the first attempt.
+	// Default is 0, no limit. The interval before the last retry is shortened not to exceed it.
+	MaxElapsedTime time.Duration
+	// InitialDelay is the wait duration before the first attempt,
+	// e.g. to stagger startup across a fleet. Default is 0, no wait.
+	// It extends the default timeout rather than consuming it.
+	InitialDelay time.Duration
+	// MaxAttempts is the maximum number of retries. Default is 0.
+	// If set 0, it will prioritize timeout. Otherwise it disables only the default timeout,
+	// and whichever of MaxAttempts and Context comes first stops retrying.
+	MaxAttempts int
+
+	attempt int
+}
+
+func (c *Custom) calc() time.Duration {
+	c.attempt++
+	return c.IntervalAt(c.attempt, 0)
+}
+
+// IntervalAt returns the interval before the given retry, which starts at 1, computed by Backoff.
+// seed is ignored since Backoff draws its own randomness if any.
+func (c Custom) IntervalAt(attempt int, seed int64) time.Duration {
+	if d := c.Backoff.Calc(attempt); 0 < d {
+		return d
+	}
+	return 0
+}
+
+func (c *Custom) reset() {
+	c.attempt = 0
+}
+
+func (c Custom) new() retrier {
+	if c.Backoff == nil {
+		// Fail at creation rather than on the first retry.
+		panic("retry: Custom requires a Backoff")
+	}
+	return retrier{
+		calculator:   &c,
+		ctx:          c.Context,
+		deadline:     c.Deadline,
+		maxElapsed:   c.MaxElapsedTime,
+		initialDelay: c.InitialDelay,
+		maxAttempts:  c.MaxAttempts,
+	}
+}
+
+// exponentialCeiling returns min(max, base * (2 ^ attempt)).
+func exponentialCeiling(base, max time.Duration, attempt int) float64 {
+	return math.Min(float64(max), float64(base)*math.Pow(2, float64(attempt)))
+}
@@ -2,6 +2,12 @@ package retry
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sync"
 	"testing"
 	"time"
 )
@@ -9,11 +15,11 @@ import (
 func TestConstant(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
-		name                 string
-		algorithm            algorithm
-		exactAttempts        int
-		leastAttempts        int
-		durationForOverwrite time.Duration
+		name           string
+		algorithm      Algorithm
+		exactAttempts  int
+		leastAttempts  int
+		defaultTimeout time.Duration
 	}{
 		{
 			name: "timeout",
@@ -37,29 +43,34 @@ func TestConstant(t *testing.T) {
 				Interval:    time.Millisecond,
 				MaxAttempts: 5,
 			},
-			exactAttempts:        5,
-			durationForOverwrite: time.Millisecond,
+			exactAttempts:  5,
+			defaultTimeout: time.Millisecond,
 		},
 		{
-			name:                 "default",
-			algorithm:            Constant{},
-			leastAttempts:        3,
-			durationForOverwrite: 3 * time.Second,
+			name:           "default",
+			algorithm:      Constant{},
+			leastAttempts:  3,
+			defaultTimeout: 3 * time.Second,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.durationForOverwrite != 0 {
-				overwrite_defaltTimeoutDuration(t, tt.durationForOverwrite)
+			var opts []Option
+			if tt.defaultTimeout != 0 {
+				opts = append(opts, WithDefaultTimeout(tt.defaultTimeout))
 			}
-			r := New(tt.algorithm)
-			t.Logf("algorithm: %#v", r)
+			if tt.exactAttempts != 0 {
+				// Max attempts bound the loop, so it runs instantly by a fake clock.
+				opts = append(opts, WithClock(&fakeClock{}))
+			}
+			r := New(tt.algorithm, opts...)
+			t.Logf("algorithm: %v", tt.algorithm)
 			attempts := 0
-			start := time.Now()
+			start := r.timeNow()
 			for r.Next() {
-				d := time.Since(start)
+				d := r.since(start)
 				t.Logf("attempt %d, %s elapsed", attempts, d)
-				start = time.Now()
+				start = r.timeNow()
 				attempts++
 			}
 			if tt.leastAttempts == 0 && attempts != tt.exactAttempts {
@@ -72,15 +83,49 @@ func TestConstant(t *testing.T) {
 	}
 }
 
+func TestConstant_defaultTimeout(t *testing.T) {
+	t.Parallel()
+	r := New(Constant{})
+	start := time.Now()
+	if !r.Next() {
+		t.Fatal("expected the first attempt")
+	}
+	deadline, ok := r.ctx.Deadline()
+	if !ok {
+		t.Fatal("expected a bare Constant to be bounded by the default timeout")
+	}
+	if d := deadline.Sub(start); d < defaultTimeoutDuration || defaultTimeoutDuration+time.Second < d {
+		t.Fatalf("expected the default timeout of %s, actual: %s", defaultTimeoutDuration, d)
+	}
+	r.cancelTimeout()
+	// At the default interval of 1 second, it attempts at 0s, 1s, ..., 59s.
+	if n := attemptCap(Constant{}); n != 60 {
+		t.Fatalf("expected 60 attempts within the default timeout, actual: %v", n)
+	}
+
+	r = New(Constant{}, WithDefaultTimeout(2500*time.Millisecond))
+	attempts := 0
+	for r.Next() {
+		attempts++
+	}
+	// It attempts at 0s, 1s and 2s, then times out while waiting.
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, actual: %d", attempts)
+	}
+	if r.reason != Timeout {
+		t.Fatalf("expected to stop by %s, actual: %s", Timeout, r.reason)
+	}
+}
+
 func TestJitter(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
-		name                 string
-		algorithm            algorithm
-		exactAttempts        int
-		leastAttempts        int
-		mostDuration         time.Duration
-		durationForOverwrite time.Duration
+		name           string
+		algorithm      Algorithm
+		exactAttempts  int
+		leastAttempts  int
+		mostDuration   time.Duration
+		defaultTimeout time.Duration
 	}{
 		{
 			name: "timeout",
@@ -104,8 +149,8 @@ func TestJitter(t *testing.T) {
 				Base:        time.Millisecond,
 				MaxAttempts: 5,
 			},
-			exactAttempts:        5,
-			durationForOverwrite: time.Millisecond,
+			exactAttempts:  5,
+			defaultTimeout: time.Millisecond,
 		},
 		{
 			name: "max duration",
@@ -118,29 +163,34 @@ func TestJitter(t *testing.T) {
 			exactAttempts: 10,
 		},
 		{
-			name:                 "default",
-			algorithm:            Jitter{},
-			leastAttempts:        2,
-			durationForOverwrite: 3 * time.Second,
+			name:           "default",
+			algorithm:      Jitter{},
+			leastAttempts:  2,
+			defaultTimeout: 3 * time.Second,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.durationForOverwrite != 0 {
-				overwrite_defaltTimeoutDuration(t, tt.durationForOverwrite)
+			var opts []Option
+			if tt.defaultTimeout != 0 {
+				opts = append(opts, WithDefaultTimeout(tt.defaultTimeout))
 			}
-			r := New(tt.algorithm)
-			t.Logf("algorithm: %#v", r)
+			if tt.exactAttempts != 0 {
+				// Max attempts bound the loop, so it runs instantly by a fake clock.
+				opts = append(opts, WithClock(&fakeClock{}))
+			}
+			r := New(tt.algorithm, opts...)
+			t.Logf("algorithm: %v", tt.algorithm)
 			attempts := 0
-			start := time.Now()
+			start := r.timeNow()
 			for r.Next() {
-				d := time.Since(start)
+				d := r.since(start)
 				if tt.mostDuration != 0 && d > tt.mostDuration {
 					t.Fatalf("expected not to exceed %s at most, actual %d", tt.mostDuration, d)
 				}
 				t.Logf("attempt %d, %s elapsed", attempts, d)
 				attempts++
-				start = time.Now()
+				start = r.timeNow()
 			}
 			if tt.leastAttempts == 0 && attempts != tt.exactAttempts {
 				t.Fatalf("expected to reach %d attempts, actual: %d", tt.exactAttempts, attempts)
@@ -155,12 +205,12 @@ func TestJitter(t *testing.T) {
 func TestExponentialBackoff(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
-		name                 string
-		algorithm            algorithm
-		exactAttempts        int
-		leastAttempts        int
-		mostDuration         time.Duration
-		durationForOverwrite time.Duration
+		name           string
+		algorithm      Algorithm
+		exactAttempts  int
+		leastAttempts  int
+		mostDuration   time.Duration
+		defaultTimeout time.Duration
 	}{
 		{
 			name: "timeout",
@@ -184,8 +234,8 @@ func TestExponentialBackoff(t *testing.T) {
 				Base:        time.Millisecond,
 				MaxAttempts: 5,
 			},
-			exactAttempts:        5,
-			durationForOverwrite: time.Millisecond,
+			exactAttempts:  5,
+			defaultTimeout: time.Millisecond,
 		},
 		{
 			name: "max duration",
@@ -198,23 +248,28 @@ func TestExponentialBackoff(t *testing.T) {
 			exactAttempts: 10,
 		},
 		{
-			name:                 "default",
-			algorithm:            ExponentialBackoff{},
-			leastAttempts:        2,
-			durationForOverwrite: 3 * time.Second,
+			name:           "default",
+			algorithm:      ExponentialBackoff{},
+			leastAttempts:  2,
+			defaultTimeout: 3 * time.Second,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if tt.durationForOverwrite != 0 {
-				overwrite_defaltTimeoutDuration(t, tt.durationForOverwrite)
+			var opts []Option
+			if tt.defaultTimeout != 0 {
+				opts = append(opts, WithDefaultTimeout(tt.defaultTimeout))
 			}
-			r := New(tt.algorithm)
-			t.Logf("algorithm: %#v", r)
+			if tt.exactAttempts != 0 {
+				// Max attempts bound the loop, so it runs instantly by a fake clock.
+				opts = append(opts, WithClock(&fakeClock{}))
+			}
+			r := New(tt.algorithm, opts...)
+			t.Logf("algorithm: %v", tt.algorithm)
 			attempts := 0
-			start := time.Now()
+			start := r.timeNow()
 			for r.Next() {
-				d := time.Since(start)
+				d := r.since(start)
 				t.Logf("attempt %d, %s elapsed", attempts, d)
 				if tt.mostDuration != 0 && d > tt.mostDuration {
 					t.Fatalf("expected not to exceed %s at most, actual %d", tt.mostDuration, d)
@@ -232,14 +287,6 @@ func TestExponentialBackoff(t *testing.T) {
 	}
 }
 
-func overwrite_defaltTimeoutDuration(t *testing.T, d time.Duration) {
-	defaultTimeoutDuration = d
-	t.Cleanup(func() {
-		// reset to default not to effect other tests.
-		defaultTimeoutDuration = time.Minute
-	})
-}
-
 func timeoutCtx(d time.Duration) context.Context {
 	ctx, cancel := context.WithTimeout(context.Background(), d)
 	go func() {
@@ -286,3 +333,1715 @@ func TestExponentialBackoff_calc(t *testing.T) {
 		prev = d
 	}
 }
+
+func TestLinear_calc(t *testing.T) {
+	t.Parallel()
+	l := Linear{
+		Base:      time.Second,
+		Increment: 2 * time.Second,
+		Max:       6 * time.Second,
+	}
+	expected := []time.Duration{
+		time.Second,
+		3 * time.Second,
+		5 * time.Second,
+		6 * time.Second,
+		6 * time.Second,
+	}
+	for i, e := range expected {
+		if d := l.calc(); d != e {
+			t.Fatalf("calc %d, expected: %s, actual: %s", i, e, d)
+		}
+	}
+}
+
+func TestLinearJitter_calc(t *testing.T) {
+	t.Parallel()
+	const samples = 1000
+	spread := 500 * time.Millisecond
+	sums := make([]time.Duration, 5)
+	draws := make([]map[time.Duration]bool, len(sums))
+	for i := range draws {
+		draws[i] = map[time.Duration]bool{}
+	}
+	for seed := int64(0); seed < samples; seed++ {
+		l := LinearJitter{
+			Base:      time.Second,
+			Increment: time.Second,
+			Spread:    spread,
+			Max:       time.Hour,
+			seed:      seed,
+		}
+		for i := range sums {
+			nominal := time.Duration(i+1) * time.Second
+			d := l.calc()
+			if d < nominal-spread || nominal+spread < d {
+				t.Fatalf("calc %d, expected to be within %s±%s, actual: %s", i, nominal, spread, d)
+			}
+			sums[i] += d
+			draws[i][d] = true
+		}
+	}
+	for i, sum := range sums {
+		nominal := time.Duration(i+1) * time.Second
+		if mean := sum / samples; mean < nominal-spread/10 || nominal+spread/10 < mean {
+			t.Fatalf("calc %d, expected the mean to grow linearly around %s, actual: %s", i, nominal, mean)
+		}
+		if len(draws[i]) < samples/2 {
+			t.Fatalf("calc %d, expected randomized draws, actual: %d distinct", i, len(draws[i]))
+		}
+	}
+}
+
+func TestFibonacci_calc(t *testing.T) {
+	t.Parallel()
+	f := Fibonacci{
+		Base: time.Millisecond,
+		Max:  time.Second,
+	}
+	expected := []time.Duration{1, 1, 2, 3, 5, 8, 13}
+	prev := time.Duration(0)
+	for i := 0; i < 30; i++ {
+		d := f.calc()
+		t.Logf("calc %d, %s", i, d)
+		if i < len(expected) && d != expected[i]*time.Millisecond {
+			t.Fatalf("calc %d, expected: %s, actual: %s", i, expected[i]*time.Millisecond, d)
+		}
+		if d < prev {
+			t.Fatalf("calculated duration must not be less than previous one")
+		}
+		if f.Max < d {
+			t.Fatalf("calculated duration must not exceed %s, actual: %s", f.Max, d)
+		}
+		prev = d
+	}
+	if prev != f.Max {
+		t.Fatalf("expected to reach %s, actual: %s", f.Max, prev)
+	}
+}
+
+func TestDecorrelatedJitter_calc(t *testing.T) {
+	t.Parallel()
+	for seed := int64(0); seed < 100; seed++ {
+		d := DecorrelatedJitter{
+			Base: time.Millisecond,
+			Max:  time.Second,
+			seed: seed,
+		}
+		prev := d.Base
+		for i := 0; i < 30; i++ {
+			sleep := d.calc()
+			if sleep < d.Base || prev*3 < sleep || d.Max < sleep {
+				t.Fatalf("calc %d, expected to be within [%s, min(%s, %s)], actual: %s", i, d.Base, d.Max, prev*3, sleep)
+			}
+			prev = sleep
+		}
+	}
+}
+
+func TestFullJitter_calc(t *testing.T) {
+	t.Parallel()
+	base, max := time.Millisecond, time.Second
+	var min time.Duration = max
+	for seed := int64(0); seed < 100; seed++ {
+		f := FullJitter{
+			Base: base,
+			Max:  max,
+			seed: seed,
+		}
+		for i := 1; i <= 30; i++ {
+			ceiling := time.Duration(math.Min(float64(max), float64(base)*math.Pow(2, float64(i))))
+			d := f.calc()
+			if d < 0 || ceiling < d {
+				t.Fatalf("calc %d, expected to be within [0, %s], actual: %s", i, ceiling, d)
+			}
+			if d < min {
+				min = d
+			}
+		}
+	}
+	if base < min {
+		t.Fatalf("expected some intervals close to zero, actual minimum: %s", min)
+	}
+}
+
+func TestEqualJitter_calc(t *testing.T) {
+	t.Parallel()
+	base, max := time.Millisecond, time.Second
+	for seed := int64(0); seed < 100; seed++ {
+		e := EqualJitter{
+			Base: base,
+			Max:  max,
+			seed: seed,
+		}
+		for i := 1; i <= 30; i++ {
+			temp := time.Duration(math.Min(float64(max), float64(base)*math.Pow(2, float64(i))))
+			d := e.calc()
+			if d < temp/2 || temp < d {
+				t.Fatalf("calc %d, expected to be within [%s, %s], actual: %s", i, temp/2, temp, d)
+			}
+		}
+	}
+}
+
+func TestExponentialBackoff_PhaseJitter(t *testing.T) {
+	t.Parallel()
+	curve := func(key string) (*ExponentialBackoff, []time.Duration) {
+		r := New(ExponentialBackoff{Base: time.Millisecond, Max: time.Hour, Key: key, PhaseJitter: true})
+		b := r.calculator.(*ExponentialBackoff)
+		var ds []time.Duration
+		for i := 0; i < 10; i++ {
+			ds = append(ds, b.calc())
+		}
+		return b, ds
+	}
+	a, as := curve("a")
+	b, bs := curve("b")
+	if a.phase(a.seed) == b.phase(b.seed) {
+		t.Fatalf("expected different phases, actual: %v", a.phase(a.seed))
+	}
+	for _, c := range []struct {
+		b  *ExponentialBackoff
+		ds []time.Duration
+	}{{a, as}, {b, bs}} {
+		phase := c.b.phase(c.b.seed)
+		if phase < 0 || 1 <= phase {
+			t.Fatalf("phase must be in [0, 1), actual: %v", phase)
+		}
+		// Every interval of the curve is shifted by the same phase.
+		for i, d := range c.ds {
+			temp := float64(time.Millisecond) * math.Pow(2, float64(i+1)+phase)
+			if float64(d) < math.Floor(temp/2) || math.Ceil(temp) < float64(d) {
+				t.Fatalf("retry #%d must be in [%v, %v], actual: %s", i+1, time.Duration(temp/2), time.Duration(temp), d)
+			}
+		}
+	}
+	if _, ds := curve("a"); !reflect.DeepEqual(as, ds) {
+		t.Fatalf("expected the same curve for the same key, expected: %v, actual: %v", as, ds)
+	}
+}
+
+func TestExponentialBackoff_overflow(t *testing.T) {
+	t.Parallel()
+	r := New(ExponentialBackoff{
+		Base: time.Second,
+		Max:  100 * 365 * 24 * time.Hour,
+	})
+	for i := 1; i <= 2000; i++ {
+		if d := r.calc(); d <= 0 || 100*365*24*time.Hour < d {
+			t.Fatalf("calc %d, expected to be within (0, Max], actual: %s", i, d)
+		}
+	}
+}
+
+func TestExponentialBackoff_Min(t *testing.T) {
+	t.Parallel()
+	b := ExponentialBackoff{
+		Base: time.Millisecond,
+		Max:  time.Hour,
+		Min:  3 * time.Millisecond,
+	}
+	for seed := int64(0); seed < 1000; seed++ {
+		if d := b.IntervalAt(1, seed); d < b.Min {
+			t.Fatalf("expected the first retry to wait at least %s, actual: %s", b.Min, d)
+		}
+	}
+}
+
+func TestExponentialBackoff_MaxDoublings(t *testing.T) {
+	t.Parallel()
+	b := ExponentialBackoff{
+		Base:         time.Millisecond,
+		Max:          time.Hour,
+		MaxDoublings: 3,
+	}
+	nominal := 8 * time.Millisecond
+	for seed := int64(0); seed < 100; seed++ {
+		for attempt := 3; attempt <= 10; attempt++ {
+			if d := b.IntervalAt(attempt, seed); d < nominal/2 || nominal < d {
+				t.Fatalf("expected retry #%d to be within [%s, %s], actual: %s", attempt, nominal/2, nominal, d)
+			}
+		}
+	}
+}
+
+func TestExponentialBackoff_StartAtBase(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		startAtBase bool
+		min         time.Duration
+		expectedMin time.Duration
+		expectedMax time.Duration
+	}{
+		{
+			name:        "default",
+			expectedMin: time.Second,
+			expectedMax: 2 * time.Second,
+		},
+		{
+			name:        "start at base",
+			startAtBase: true,
+			expectedMin: 500 * time.Millisecond,
+			expectedMax: time.Second,
+		},
+		{
+			name:        "start at base below min",
+			startAtBase: true,
+			min:         time.Millisecond,
+			expectedMin: 500 * time.Millisecond,
+			expectedMax: time.Second,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			lowest, highest := time.Duration(math.MaxInt64), time.Duration(0)
+			for seed := int64(0); seed < 100; seed++ {
+				b := ExponentialBackoff{
+					Base:        time.Second,
+					Max:         time.Hour,
+					Min:         tt.min,
+					StartAtBase: tt.startAtBase,
+					seed:        seed,
+				}
+				d := b.calc()
+				if d < tt.expectedMin || tt.expectedMax < d {
+					t.Fatalf("expected the first retry to be within [%s, %s], actual: %s", tt.expectedMin, tt.expectedMax, d)
+				}
+				lowest, highest = min(lowest, d), max(highest, d)
+			}
+			// The first retry is jittered, so seeds spread it over most of the range.
+			if spread := highest - lowest; spread < (tt.expectedMax-tt.expectedMin)/2 {
+				t.Fatalf("expected the first retry to spread across seeds, actual: [%s, %s]", lowest, highest)
+			}
+		})
+	}
+}
+
+func TestConstantJitter_calc(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name     string
+		jitter   time.Duration
+		fraction float64
+		mode     JitterMode
+		min, max time.Duration
+	}{
+		{
+			name:   "symmetric",
+			jitter: 2 * time.Millisecond,
+			mode:   Symmetric,
+			min:    8 * time.Millisecond,
+			max:    12 * time.Millisecond,
+		},
+		{
+			name:   "positive only",
+			jitter: 2 * time.Millisecond,
+			mode:   PositiveOnly,
+			min:    10 * time.Millisecond,
+			max:    12 * time.Millisecond,
+		},
+		{
+			name:     "fraction",
+			fraction: 0.1,
+			mode:     Symmetric,
+			min:      9 * time.Millisecond,
+			max:      11 * time.Millisecond,
+		},
+		{
+			name:     "jitter takes precedence over fraction",
+			jitter:   3 * time.Millisecond,
+			fraction: 0.1,
+			mode:     Symmetric,
+			min:      7 * time.Millisecond,
+			max:      13 * time.Millisecond,
+		},
+		{
+			name: "default",
+			mode: Symmetric,
+			min:  5 * time.Millisecond,
+			max:  15 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ConstantJitter{
+				Interval: 10 * time.Millisecond,
+				Jitter:   tt.jitter,
+				Fraction: tt.fraction,
+				Mode:     tt.mode,
+			}.withDefaults()
+			var lowest, highest time.Duration = tt.max, tt.min
+			for i := 0; i < 1000; i++ {
+				d := c.calc()
+				if d < tt.min || tt.max < d {
+					t.Fatalf("calc %d, expected to be within [%s, %s], actual: %s", i, tt.min, tt.max, d)
+				}
+				lowest, highest = min(lowest, d), max(highest, d)
+			}
+			// Uniform draws should spread over most of the range.
+			if spread := tt.max - tt.min; highest-lowest < spread*9/10 {
+				t.Fatalf("expected draws to spread over [%s, %s], actual: [%s, %s]", tt.min, tt.max, lowest, highest)
+			}
+		})
+	}
+}
+
+func TestRetrier_Schedule(t *testing.T) {
+	t.Parallel()
+	interval := time.Hour
+	r := New(Constant{
+		Interval:    interval,
+		MaxAttempts: 3,
+	})
+	var scheduled []time.Duration
+	for {
+		fired := false
+		now := time.Now()
+		r.Schedule(func(at time.Time) {
+			fired = true
+			scheduled = append(scheduled, at.Sub(now))
+		})
+		if !fired {
+			break
+		}
+		r.Fire()
+	}
+	if r.attempts != 3 {
+		t.Fatalf("expected to reach 3 attempts, actual: %v", r.attempts)
+	}
+	if len(scheduled) != 3 {
+		t.Fatalf("expected to schedule 3 attempts, actual: %d", len(scheduled))
+	}
+	if scheduled[0] >= interval {
+		t.Fatalf("expected to schedule the first attempt immediately, actual: %s later", scheduled[0])
+	}
+	for i, d := range scheduled[1:] {
+		if d < interval {
+			t.Fatalf("expected to schedule attempt %d at least %s later, actual: %s", i+1, interval, d)
+		}
+	}
+}
+
+func TestRetrier_NextDelay(t *testing.T) {
+	t.Parallel()
+	r := New(Linear{
+		Base:         time.Hour,
+		Max:          10 * time.Hour,
+		InitialDelay: time.Minute,
+		MaxAttempts:  3,
+	})
+	start := time.Now()
+	var delays []time.Duration
+	for {
+		d, ok := r.NextDelay()
+		if !ok {
+			break
+		}
+		delays = append(delays, d)
+	}
+	if elapsed := time.Since(start); time.Second < elapsed {
+		t.Fatalf("expected not to wait, actual: %s", elapsed)
+	}
+	expected := []time.Duration{time.Minute, time.Hour, 2 * time.Hour}
+	if !reflect.DeepEqual(delays, expected) {
+		t.Fatalf("expected %v, actual: %v", expected, delays)
+	}
+	if reason := r.StopReason(); reason != MaxAttempts {
+		t.Fatalf("expected to stop by %s, actual: %s", MaxAttempts, reason)
+	}
+}
+
+func TestRetrier_Progress(t *testing.T) {
+	t.Parallel()
+	r := New(ExponentialBackoff{
+		Base: time.Millisecond,
+		Max:  time.Hour,
+	})
+	var d time.Duration
+	for i := 0; i < 5; i++ {
+		d = r.calc()
+	}
+	if d < 16*time.Millisecond {
+		t.Fatalf("expected 5th interval to be at least 16ms, actual: %s", d)
+	}
+	r.Progress()
+	d = r.calc()
+	if 2*time.Millisecond < d {
+		t.Fatalf("expected progress to reset the interval to at most 2ms, actual: %s", d)
+	}
+	d = r.calc()
+	if d < 2*time.Millisecond || 4*time.Millisecond < d {
+		t.Fatalf("expected the interval to grow from base again, actual: %s", d)
+	}
+}
+
+// backwardClock simulates a wall clock which NTP steps backward by an hour
+// every time it's read. last holds the time read most recently.
+type backwardClock struct {
+	last time.Time
+}
+
+func (c *backwardClock) Now() time.Time {
+	if c.last.IsZero() {
+		// Round(0) strips the monotonic clock reading.
+		c.last = time.Now().Round(0)
+	}
+	c.last = c.last.Add(-time.Hour)
+	return c.last
+}
+
+func (c *backwardClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func TestRetrier_wallClockStepsBackward(t *testing.T) {
+	t.Parallel()
+	interval := time.Millisecond
+	t.Run("schedule", func(t *testing.T) {
+		clock := &backwardClock{}
+		r := New(Constant{
+			Interval:    interval,
+			MaxAttempts: 5,
+		})
+		r.clock = clock
+		for i := 0; i < 5; i++ {
+			r.Schedule(func(at time.Time) {
+				d := at.Sub(clock.last)
+				if d < 0 || interval < d {
+					t.Fatalf("expected to schedule attempt %d within %s, actual: %s", i, interval, d)
+				}
+			})
+			r.Fire()
+		}
+	})
+	t.Run("do", func(t *testing.T) {
+		r := New(Constant{
+			Interval:    interval,
+			MaxAttempts: 5,
+		})
+		r.clock = &backwardClock{}
+		_, res, _ := run(r, func() (struct{}, error) {
+			return struct{}{}, errors.New("error")
+		})
+		if res.Elapsed < 0 {
+			t.Fatalf("expected elapsed not to be negative, actual: %s", res.Elapsed)
+		}
+		if res.SleptTotal < 0 {
+			t.Fatalf("expected slept not to be negative, actual: %s", res.SleptTotal)
+		}
+	})
+}
+
+func TestWithoutTimeout(t *testing.T) {
+	t.Parallel()
+	timeout := 5 * time.Millisecond
+	r := New(Constant{Interval: time.Millisecond}, WithDefaultTimeout(timeout), WithoutTimeout())
+	attempts := 0
+	start := time.Now()
+	for r.Next() {
+		attempts++
+		if attempts == 20 {
+			break
+		}
+	}
+	if attempts != 20 {
+		t.Fatalf("expected to reach 20 attempts, actual: %d", attempts)
+	}
+	if elapsed := time.Since(start); elapsed < timeout {
+		t.Fatalf("expected to continue past the default timeout %s, actual: %s", timeout, elapsed)
+	}
+}
+
+func TestWithTimeScale(t *testing.T) {
+	t.Parallel()
+	peak := func(now time.Time) float64 {
+		if 9 <= now.Hour() && now.Hour() < 18 {
+			return 3
+		}
+		return 1
+	}
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Duration
+	}{
+		{
+			name:     "peak",
+			now:      time.Date(2021, 10, 1, 10, 0, 0, 0, time.UTC),
+			expected: 3 * time.Second,
+		},
+		{
+			name:     "off-peak",
+			now:      time.Date(2021, 10, 1, 3, 0, 0, 0, time.UTC),
+			expected: time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(Constant{Interval: time.Second}, WithTimeScale(peak), WithClock(&fakeClock{now: tt.now}))
+			if d := r.interval(); d != tt.expected {
+				t.Fatalf("expected %s, actual: %s", tt.expected, d)
+			}
+		})
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	t.Parallel()
+	start := time.Now()
+	deadline := start.Add(100 * time.Millisecond)
+	r := New(Constant{
+		Interval: 30 * time.Millisecond,
+		Deadline: deadline,
+	})
+	var last time.Time
+	for r.Next() {
+		last = time.Now()
+	}
+	if r.reason != Deadline {
+		t.Fatalf("expected to stop by %s, actual: %s", Deadline, r.reason)
+	}
+	if last.Before(deadline) {
+		t.Fatalf("expected the last attempt at the deadline, actual: %s before", deadline.Sub(last))
+	}
+	if overshoot := last.Sub(deadline); 20*time.Millisecond < overshoot {
+		t.Fatalf("expected the last sleep not to overshoot the deadline, actual: %s", overshoot)
+	}
+}
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		algorithm func(key string) Algorithm
+	}{
+		{
+			name: "jitter",
+			algorithm: func(key string) Algorithm {
+				return Jitter{Key: key}
+			},
+		},
+		{
+			name: "constant jitter",
+			algorithm: func(key string) Algorithm {
+				return ConstantJitter{Key: key}
+			},
+		},
+		{
+			name: "exponential backoff",
+			algorithm: func(key string) Algorithm {
+				return ExponentialBackoff{Key: key}
+			},
+		},
+	}
+	sequence := func(a Algorithm) []time.Duration {
+		r := New(a)
+		ds := make([]time.Duration, 5)
+		for i := range ds {
+			ds[i] = r.calc()
+		}
+		return ds
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := sequence(tt.algorithm("user:1"))
+			b := sequence(tt.algorithm("user:1"))
+			c := sequence(tt.algorithm("user:2"))
+			if !reflect.DeepEqual(a, b) {
+				t.Fatalf("expected the same key to yield the same sequence, actual: %v and %v", a, b)
+			}
+			if reflect.DeepEqual(a, c) {
+				t.Fatalf("expected different keys to yield different sequences, actual: %v", a)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+	r := New(Constant{
+		Interval:    time.Hour,
+		MaxAttempts: 3,
+	}, WithClock(&fakeClock{}))
+	attempts := 0
+	loop := func(n interface{ Next() bool }) {
+		for n.Next() {
+			attempts++
+		}
+	}
+	loop(r)
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, actual: %d", attempts)
+	}
+	if r.Next() {
+		t.Fatal("expected the retrier passed around to stay exhausted")
+	}
+}
+
+func TestRetrier_Iter(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{}
+	r := New(Constant{
+		Interval:    time.Second,
+		MaxAttempts: 5,
+	}, WithClock(clock))
+	var attempts []int
+	for attempt := range r.Iter() {
+		attempts = append(attempts, attempt)
+	}
+	expected := []int{1, 2, 3, 4, 5}
+	if !reflect.DeepEqual(attempts, expected) {
+		t.Fatalf("expected attempts %v, actual: %v", expected, attempts)
+	}
+	if len(clock.waits) != 4 {
+		t.Fatalf("expected to wait between attempts 4 times, actual: %v", clock.waits)
+	}
+
+	r = New(Constant{Interval: time.Second}, WithClock(&fakeClock{}))
+	for attempt := range r.Iter() {
+		if attempt == 3 {
+			break
+		}
+	}
+	if r.attempts != 3 {
+		t.Fatalf("expected to stop iterating after 3 attempts, actual: %v", r.attempts)
+	}
+}
+
+func TestRetrier_Wait(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{}
+	r := New(Linear{Base: time.Second}, WithClock(clock))
+	for i := 0; i < 3; i++ {
+		if err := r.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	if !reflect.DeepEqual(clock.waits, expected) {
+		t.Fatalf("expected to wait %v, actual: %v", expected, clock.waits)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r = New(Constant{Interval: time.Hour})
+	if err := r.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, actual: %v", context.Canceled, err)
+	}
+}
+
+func TestRetrier_concurrent(t *testing.T) {
+	t.Parallel()
+	r := New(ExponentialBackoff{
+		Base:        time.Millisecond,
+		MaxAttempts: 100,
+	}, WithClock(&fakeClock{}))
+	var (
+		mu       sync.Mutex
+		attempts int
+		wg       sync.WaitGroup
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r.Next() {
+				mu.Lock()
+				attempts++
+				mu.Unlock()
+				r.SetErr(errTest)
+			}
+		}()
+	}
+	wg.Wait()
+	if attempts != 100 {
+		t.Fatalf("expected the workers to share 100 attempts, actual: %d", attempts)
+	}
+}
+
+func TestRetrier_notLockedWhileWaiting(t *testing.T) {
+	t.Parallel()
+	interval := 200 * time.Millisecond
+	r := New(Constant{
+		Interval:    interval,
+		MaxAttempts: 3,
+	})
+	var observed []int
+	Chain(r, func(next func() bool) func() bool {
+		return func() bool {
+			// It would deadlock if Next held the lock while calling middlewares.
+			observed = append(observed, r.Attempts())
+			r.SetErr(errTest)
+			return next()
+		}
+	})
+	var wg sync.WaitGroup
+	blocked := make(chan time.Duration, 1)
+	for attempt := range r.Iter() {
+		if attempt != 1 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Wait for the loop to start waiting for the second attempt.
+			time.Sleep(interval / 4)
+			start := time.Now()
+			r.SetErr(errTest)
+			_ = r.Attempts()
+			blocked <- time.Since(start)
+		}()
+	}
+	wg.Wait()
+	if d := <-blocked; interval/2 < d {
+		t.Fatalf("expected SetErr not to wait for Next, actual: blocked for %s", d)
+	}
+	expected := []int{0, 1, 2, 3}
+	if !reflect.DeepEqual(observed, expected) {
+		t.Fatalf("expected the middleware to observe attempts %v, actual: %v", expected, observed)
+	}
+}
+
+func TestRetrier_Reset(t *testing.T) {
+	t.Parallel()
+	r := New(ExponentialBackoff{
+		Context:     WithSeed(context.Background(), 1),
+		Base:        time.Microsecond,
+		MaxAttempts: 3,
+	})
+	loop := func() (attempts int, intervals []time.Duration) {
+		for r.Next() {
+			attempts++
+			intervals = append(intervals, r.lastInterval)
+			r.SetErr(errTest)
+		}
+		return attempts, intervals
+	}
+	attempts, intervals := loop()
+	r.Reset()
+	if r.Err() != nil {
+		t.Fatalf("expected the error to be cleared, actual: %v", r.Err())
+	}
+	reusedAttempts, reusedIntervals := loop()
+	if reusedAttempts != attempts || !reflect.DeepEqual(reusedIntervals, intervals) {
+		t.Fatalf("expected the same loop after reset, expected: %d %v, actual: %d %v", attempts, intervals, reusedAttempts, reusedIntervals)
+	}
+
+	// The default timeout starts over.
+	r = New(Constant{Interval: time.Microsecond})
+	r.Next()
+	ctx := r.ctx
+	r.Reset()
+	if ctx.Err() == nil {
+		t.Fatal("expected the previous default timeout to be canceled")
+	}
+	r.Next()
+	if r.ctx == nil || r.ctx.Err() != nil {
+		t.Fatalf("expected a new default timeout, actual: %v", r.ctx)
+	}
+}
+
+func TestRetrier_Success(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{}
+	r := New(Linear{
+		Base:        time.Second,
+		Max:         time.Hour,
+		MaxAttempts: 3,
+	}, WithClock(clock))
+	outcomes := []bool{false, false, true, false, false, true}
+	attempts := 0
+	for r.Next() {
+		if outcomes[attempts] {
+			r.Success()
+		} else {
+			r.Failure(errTest)
+		}
+		attempts++
+	}
+	if attempts != 5 {
+		t.Fatalf("expected MaxAttempts to bound consecutive failures, actual: %d attempts", attempts)
+	}
+	expected := []time.Duration{time.Second, 2 * time.Second, time.Second, 2 * time.Second}
+	if !reflect.DeepEqual(clock.waits, expected) {
+		t.Fatalf("expected the backoff to start over after success, expected: %v, actual: %v", expected, clock.waits)
+	}
+	if r.StopReason() != MaxAttempts || !errors.Is(r.Err(), errTest) {
+		t.Fatalf("expected to stop by %s with %v, actual: %s with %v", MaxAttempts, errTest, r.StopReason(), r.Err())
+	}
+}
+
+func TestRetrier_Success_maxElapsedTime(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{}
+	r := New(Constant{
+		Interval:       time.Second,
+		MaxElapsedTime: 2500 * time.Millisecond,
+	}, WithClock(clock))
+	attempts := 0
+	for r.Next() {
+		attempts++
+		if attempts == 3 {
+			r.Success()
+		}
+	}
+	// 4 attempts fit in the budget, and it restarts from the success of the 3rd one.
+	if attempts != 6 {
+		t.Fatalf("expected 6 attempts, actual: %d", attempts)
+	}
+	if r.StopReason() != MaxElapsed {
+		t.Fatalf("expected to stop by %s, actual: %s", MaxElapsed, r.StopReason())
+	}
+}
+
+func TestRetrier_SkipTo(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		n                int
+		expectedAttempts int
+		expectedWaits    []time.Duration
+	}{
+		{
+			name:             "resumes the backoff",
+			n:                4,
+			expectedAttempts: 2,
+			expectedWaits:    []time.Duration{4 * time.Second, 5 * time.Second},
+		},
+		{
+			name:             "beyond max attempts",
+			n:                10,
+			expectedAttempts: 0,
+		},
+		{
+			name:             "zero",
+			n:                0,
+			expectedAttempts: 6,
+			expectedWaits:    []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, 5 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			clock := &fakeClock{}
+			r := New(Linear{
+				Base:        time.Second,
+				Max:         time.Hour,
+				MaxAttempts: 6,
+			}, WithClock(clock))
+			r.SkipTo(tt.n)
+			attempts := 0
+			for r.Next() {
+				attempts++
+			}
+			if attempts != tt.expectedAttempts {
+				t.Fatalf("expected %d attempts, actual: %d", tt.expectedAttempts, attempts)
+			}
+			if !reflect.DeepEqual(clock.waits, tt.expectedWaits) {
+				t.Fatalf("expected waits %v, actual: %v", tt.expectedWaits, clock.waits)
+			}
+			if r.StopReason() != MaxAttempts {
+				t.Fatalf("expected to stop by %s, actual: %s", MaxAttempts, r.StopReason())
+			}
+		})
+	}
+}
+
+func TestRetrier_Clone(t *testing.T) {
+	t.Parallel()
+	r := New(Linear{
+		Base:        time.Hour,
+		Max:         2 * time.Hour,
+		MaxAttempts: 10,
+	}, WithMaxAttempts(4), WithClock(&fakeClock{}))
+	loop := func(r *retrier) (intervals []time.Duration) {
+		for r.Next() {
+			intervals = append(intervals, r.lastInterval)
+		}
+		return intervals
+	}
+	r.Next()
+	c := r.Clone()
+	if c.Attempts() != 0 {
+		t.Fatalf("expected a fresh clone, actual: %d attempts", c.Attempts())
+	}
+	expected := []time.Duration{0, time.Hour, 2 * time.Hour, 2 * time.Hour}
+	if actual := loop(c); !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("expected %v, actual: %v", expected, actual)
+	}
+	if r.Attempts() != 1 {
+		t.Fatalf("expected the clone not to advance the original, actual: %d attempts", r.Attempts())
+	}
+
+	j := New(Jitter{MaxAttempts: 3})
+	if j.calculator.(*Jitter).seed == j.Clone().calculator.(*Jitter).seed {
+		t.Fatal("expected a clone of a jittered algorithm to draw a new seed")
+	}
+}
+
+func TestRetrier_Err(t *testing.T) {
+	t.Parallel()
+	r := New(Constant{
+		Interval:    time.Millisecond,
+		MaxAttempts: 3,
+	})
+	if err := r.Err(); err != nil {
+		t.Fatalf("expected no error before the loop, actual: %v", err)
+	}
+	attempts := 0
+	for r.Next() {
+		attempts++
+		r.SetErr(fmt.Errorf("attempt %d: %w", attempts, errTest))
+	}
+	if err := r.Err(); !errors.Is(err, errTest) || err.Error() != "attempt 3: test" {
+		t.Fatalf("expected the error of the last attempt, actual: %v", err)
+	}
+}
+
+func TestRetrier_Elapsed(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	r := New(Constant{
+		Interval:     time.Second,
+		InitialDelay: time.Minute,
+		MaxAttempts:  4,
+	}, WithClock(clock))
+	if d := r.Elapsed(); d != 0 {
+		t.Fatalf("expected zero before the first Next, actual: %v", d)
+	}
+	for r.Next() {
+	}
+	if a := r.Attempts(); a != 4 {
+		t.Fatalf("expected 4 attempts, actual: %d", a)
+	}
+	// The initial delay precedes the first attempt, so it is not counted.
+	if d := r.Elapsed(); d != 3*time.Second {
+		t.Fatalf("expected 3s since the first attempt, actual: %v", d)
+	}
+	r.Reset()
+	if d := r.Elapsed(); d != 0 {
+		t.Fatalf("expected zero after Reset, actual: %v", d)
+	}
+}
+
+func TestDefaultMax(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		algorithm Algorithm
+	}{
+		{name: "jitter", algorithm: Jitter{}},
+		{name: "exponential backoff", algorithm: ExponentialBackoff{}},
+		{name: "linear", algorithm: Linear{}},
+		{name: "linear jitter", algorithm: LinearJitter{}},
+		{name: "fibonacci", algorithm: Fibonacci{}},
+		{name: "full jitter", algorithm: FullJitter{}},
+		{name: "equal jitter", algorithm: EqualJitter{}},
+		{name: "adaptive", algorithm: Adaptive{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.algorithm.new()
+			if max := r.calculator.(capper).maxInterval(); max != 15*time.Second {
+				t.Fatalf("expected the documented default Max 15s, actual: %s", max)
+			}
+		})
+	}
+}
+
+func TestBaseAboveMax(t *testing.T) {
+	t.Parallel()
+	base, max := time.Minute, time.Second
+	tests := []struct {
+		name      string
+		algorithm Algorithm
+		min       time.Duration
+	}{
+		{name: "jitter", algorithm: Jitter{Base: base, Max: max}, min: max},
+		{name: "exponential backoff", algorithm: ExponentialBackoff{Base: base, Max: max}, min: max},
+		{name: "linear", algorithm: Linear{Base: base, Max: max}, min: max},
+		{name: "linear jitter", algorithm: LinearJitter{Base: base, Max: max}, min: max / 2},
+		{name: "fibonacci", algorithm: Fibonacci{Base: base, Max: max}, min: max},
+		{name: "full jitter", algorithm: FullJitter{Base: base, Max: max}, min: 0},
+		{name: "equal jitter", algorithm: EqualJitter{Base: base, Max: max}, min: max / 2},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			for i, d := range Preview(tt.algorithm, 100) {
+				if d < tt.min || max < d {
+					t.Fatalf("interval %d, expected to be within [%s, %s], actual: %s", i, tt.min, max, d)
+				}
+			}
+		})
+	}
+}
+
+func TestIntervalAt(t *testing.T) {
+	t.Parallel()
+	const seed = 42
+	ctx := WithSeed(context.Background(), seed)
+	tests := []struct {
+		name      string
+		algorithm interface {
+			Algorithm
+			IntervalAt(attempt int, seed int64) time.Duration
+		}
+	}{
+		{
+			name:      "constant",
+			algorithm: Constant{Context: ctx},
+		},
+		{
+			name:      "jitter",
+			algorithm: Jitter{Context: ctx},
+		},
+		{
+			name:      "constant jitter",
+			algorithm: ConstantJitter{Context: ctx},
+		},
+		{
+			name:      "linear",
+			algorithm: Linear{Context: ctx, Increment: time.Millisecond},
+		},
+		{
+			name:      "linear jitter",
+			algorithm: LinearJitter{Context: ctx},
+		},
+		{
+			name:      "fibonacci",
+			algorithm: Fibonacci{Context: ctx, Max: time.Minute},
+		},
+		{
+			name:      "full jitter",
+			algorithm: FullJitter{Context: ctx},
+		},
+		{
+			name:      "equal jitter",
+			algorithm: EqualJitter{Context: ctx},
+		},
+		{
+			name:      "exponential backoff",
+			algorithm: ExponentialBackoff{Context: ctx, Max: time.Hour, PhaseJitter: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(tt.algorithm)
+			for attempt := 1; attempt <= 10; attempt++ {
+				expected := r.calc()
+				if actual := tt.algorithm.IntervalAt(attempt, seed); actual != expected {
+					t.Fatalf("expected retry #%d to match the stateful sequence %s, actual: %s", attempt, expected, actual)
+				}
+				if a, b := tt.algorithm.IntervalAt(attempt, seed), tt.algorithm.IntervalAt(attempt, seed); a != b {
+					t.Fatalf("expected retry #%d to be deterministic, actual: %s and %s", attempt, a, b)
+				}
+			}
+		})
+	}
+}
+
+func TestRand(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		algorithm func(rnd *rand.Rand) Algorithm
+	}{
+		{
+			name: "jitter",
+			algorithm: func(rnd *rand.Rand) Algorithm {
+				return Jitter{Rand: rnd}
+			},
+		},
+		{
+			name: "full jitter",
+			algorithm: func(rnd *rand.Rand) Algorithm {
+				return FullJitter{Rand: rnd}
+			},
+		},
+		{
+			name: "linear jitter",
+			algorithm: func(rnd *rand.Rand) Algorithm {
+				return LinearJitter{Rand: rnd}
+			},
+		},
+		{
+			name: "equal jitter",
+			algorithm: func(rnd *rand.Rand) Algorithm {
+				return EqualJitter{Rand: rnd}
+			},
+		},
+		{
+			name: "exponential backoff",
+			algorithm: func(rnd *rand.Rand) Algorithm {
+				return ExponentialBackoff{Rand: rnd}
+			},
+		},
+	}
+	sequence := func(a Algorithm) []time.Duration {
+		r := New(a)
+		ds := make([]time.Duration, 5)
+		for i := range ds {
+			ds[i] = r.calc()
+		}
+		return ds
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := sequence(tt.algorithm(rand.New(rand.NewSource(1))))
+			b := sequence(tt.algorithm(rand.New(rand.NewSource(1))))
+			c := sequence(tt.algorithm(rand.New(rand.NewSource(2))))
+			if !reflect.DeepEqual(a, b) {
+				t.Fatalf("expected the same source to yield the same sequence, actual: %v and %v", a, b)
+			}
+			if reflect.DeepEqual(a, c) {
+				t.Fatalf("expected different sources to yield different sequences, actual: %v", a)
+			}
+		})
+	}
+}
+
+func TestRetrier_SetNextDelay(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{}
+	r := New(Linear{
+		Base:        time.Second,
+		Max:         10 * time.Second,
+		MaxAttempts: 5,
+	}, WithClock(clock))
+	attempt := 0
+	for r.Next() {
+		attempt++
+		switch attempt {
+		case 2:
+			r.SetNextDelay(7 * time.Second)
+		case 3:
+			r.SetNextDelay(time.Hour)
+		}
+	}
+	expected := []time.Duration{time.Second, 7 * time.Second, 10 * time.Second, 4 * time.Second}
+	if !reflect.DeepEqual(clock.waits, expected) {
+		t.Fatalf("expected to wait %v, actual: %v", expected, clock.waits)
+	}
+}
+
+func TestMaxElapsedTime(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		maxAttempts      int
+		expectedAttempts int
+		expectedReason   StopReason
+	}{
+		{
+			name:             "max elapsed time",
+			expectedAttempts: 5,
+			expectedReason:   MaxElapsed,
+		},
+		{
+			name:             "max attempts hit first",
+			maxAttempts:      3,
+			expectedAttempts: 3,
+			expectedReason:   MaxAttempts,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(Constant{
+				Interval:       time.Second,
+				MaxElapsedTime: 3500 * time.Millisecond,
+				MaxAttempts:    tt.maxAttempts,
+			}, WithClock(&fakeClock{}))
+			attempts := 0
+			for r.Next() {
+				attempts++
+			}
+			if attempts != tt.expectedAttempts {
+				t.Fatalf("expected %d attempts, actual: %d", tt.expectedAttempts, attempts)
+			}
+			if r.reason != tt.expectedReason {
+				t.Fatalf("expected to stop by %s, actual: %s", tt.expectedReason, r.reason)
+			}
+			if r.ctx.Done() != nil {
+				t.Fatal("expected MaxElapsedTime to disable the default timeout")
+			}
+		})
+	}
+}
+
+func TestMaxElapsedTime_lastWait(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{}
+	r := New(ExponentialBackoff{
+		Base:           2 * time.Minute,
+		Max:            time.Hour,
+		MaxElapsedTime: 30 * time.Second,
+	}, WithClock(clock))
+	attempts := 0
+	for r.Next() {
+		attempts++
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, actual: %d", attempts)
+	}
+	if r.reason != MaxElapsed {
+		t.Fatalf("expected to stop by %s, actual: %s", MaxElapsed, r.reason)
+	}
+	expected := []time.Duration{30 * time.Second}
+	if !reflect.DeepEqual(clock.waits, expected) {
+		t.Fatalf("expected waits %v, actual: %v", expected, clock.waits)
+	}
+}
+
+func TestDeadline_withContext(t *testing.T) {
+	t.Parallel()
+	tight := 30 * time.Millisecond
+	loose := 60 * time.Millisecond
+	tests := []struct {
+		name       string
+		ctx        time.Duration
+		deadline   time.Duration
+		maxElapsed time.Duration
+		// slow makes an attempt outlive both bounds.
+		slow     bool
+		expected StopReason
+	}{
+		{
+			name:     "context only",
+			ctx:      tight,
+			expected: Timeout,
+		},
+		{
+			name:     "deadline only",
+			deadline: tight,
+			expected: Deadline,
+		},
+		{
+			name:     "context is tighter",
+			ctx:      tight,
+			deadline: loose,
+			expected: Timeout,
+		},
+		{
+			name:     "deadline is tighter",
+			ctx:      loose,
+			deadline: tight,
+			expected: Deadline,
+		},
+		{
+			name:     "both passed during an attempt and context is tighter",
+			ctx:      tight,
+			deadline: loose,
+			slow:     true,
+			expected: Timeout,
+		},
+		{
+			name:     "both passed during an attempt and deadline is tighter",
+			ctx:      loose,
+			deadline: tight,
+			slow:     true,
+			expected: Deadline,
+		},
+		{
+			name:       "context is tighter than max elapsed time",
+			ctx:        tight,
+			maxElapsed: loose,
+			expected:   Timeout,
+		},
+		{
+			name:       "max elapsed time is tighter than context",
+			ctx:        loose,
+			maxElapsed: tight,
+			expected:   MaxElapsed,
+		},
+		{
+			name:       "both passed during an attempt and context is tighter than max elapsed time",
+			ctx:        tight,
+			maxElapsed: loose,
+			slow:       true,
+			expected:   Timeout,
+		},
+		{
+			name:       "both passed during an attempt and max elapsed time is tighter than context",
+			ctx:        loose,
+			maxElapsed: tight,
+			slow:       true,
+			expected:   MaxElapsed,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := Constant{Interval: 5 * time.Millisecond}
+			if tt.ctx != 0 {
+				a.Context = timeoutCtx(tt.ctx)
+			}
+			if tt.deadline != 0 {
+				a.Deadline = time.Now().Add(tt.deadline)
+			}
+			a.MaxElapsedTime = tt.maxElapsed
+			res, err := DoResult(a, func() error {
+				if tt.slow {
+					time.Sleep(2 * loose)
+				}
+				return errors.New("error")
+			})
+			if res.StopReason != tt.expected {
+				t.Fatalf("expected to stop by %s, actual: %s", tt.expected, res.StopReason)
+			}
+			if tt.expected != Timeout && errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected no context error when stopped by %s, actual: %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestNewChecked(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	tests := []struct {
+		name             string
+		a                Algorithm
+		expectedWarnings int
+	}{
+		{
+			name:             "max elapsed time within the context",
+			a:                Constant{Context: ctx, MaxElapsedTime: time.Second},
+			expectedWarnings: 0,
+		},
+		{
+			name:             "max elapsed time beyond the context",
+			a:                Constant{Context: ctx, MaxElapsedTime: time.Hour},
+			expectedWarnings: 1,
+		},
+		{
+			name:             "no context",
+			a:                Constant{MaxElapsedTime: time.Hour},
+			expectedWarnings: 0,
+		},
+	}
+	for _, tt := range tests {
+		r, warnings := NewChecked(tt.a)
+		if r == nil {
+			t.Fatalf("%s: expected a retrier", tt.name)
+		}
+		if len(warnings) != tt.expectedWarnings {
+			t.Fatalf("%s: expected %d warnings, actual: %q", tt.name, tt.expectedWarnings, warnings)
+		}
+	}
+}
+
+func TestWithSeed(t *testing.T) {
+	t.Parallel()
+	sequence := func(ctx context.Context) []time.Duration {
+		r := New(Jitter{Context: ctx})
+		ds := make([]time.Duration, 5)
+		for i := range ds {
+			ds[i] = r.calc()
+		}
+		return ds
+	}
+	a := sequence(WithSeed(context.Background(), 1))
+	b := sequence(WithSeed(context.Background(), 1))
+	c := sequence(WithSeed(context.Background(), 2))
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("expected the same seed to yield the same sequence, actual: %v and %v", a, b)
+	}
+	if reflect.DeepEqual(a, c) {
+		t.Fatalf("expected different seeds to yield different sequences, actual: %v", a)
+	}
+}
+
+func TestWithSeed_withContext(t *testing.T) {
+	t.Parallel()
+	algorithms := []Algorithm{
+		Jitter{},
+		ConstantJitter{},
+		ExponentialBackoff{},
+		LinearJitter{},
+		FullJitter{},
+		EqualJitter{},
+	}
+	for _, a := range algorithms {
+		sequence := func(seed int64) []time.Duration {
+			r := New(a, WithContext(WithSeed(context.Background(), seed)))
+			ds := make([]time.Duration, 5)
+			for i := range ds {
+				ds[i] = r.calc()
+			}
+			return ds
+		}
+		x, y, z := sequence(1), sequence(1), sequence(2)
+		if !reflect.DeepEqual(x, y) {
+			t.Fatalf("%T: expected the same seed to yield the same sequence, actual: %v and %v", a, x, y)
+		}
+		if reflect.DeepEqual(x, z) {
+			t.Fatalf("%T: expected different seeds to yield different sequences, actual: %v", a, x)
+		}
+	}
+}
+
+func TestInitialDelay(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name             string
+		a                Algorithm
+		expectedAttempts int
+		expectedWaits    []time.Duration
+	}{
+		{
+			name:             "waits before the first attempt",
+			a:                Constant{Interval: time.Second, InitialDelay: 5 * time.Second, MaxAttempts: 3},
+			expectedAttempts: 3,
+			expectedWaits:    []time.Duration{5 * time.Second, time.Second, time.Second},
+		},
+		{
+			name:             "counts the first attempt against MaxAttempts",
+			a:                Constant{Interval: time.Second, InitialDelay: 5 * time.Second, MaxAttempts: 1},
+			expectedAttempts: 1,
+			expectedWaits:    []time.Duration{5 * time.Second},
+		},
+		{
+			name:             "shortened by Deadline",
+			a:                Constant{Interval: time.Second, InitialDelay: 5 * time.Second, Deadline: time.Time{}.Add(2 * time.Second)},
+			expectedAttempts: 1,
+			expectedWaits:    []time.Duration{2 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			clock := &fakeClock{}
+			r := New(tt.a, WithClock(clock))
+			attempts := 0
+			for r.Next() {
+				attempts++
+			}
+			if attempts != tt.expectedAttempts {
+				t.Fatalf("expected %d attempts, actual: %d", tt.expectedAttempts, attempts)
+			}
+			if !reflect.DeepEqual(clock.waits, tt.expectedWaits) {
+				t.Fatalf("expected waits %v, actual: %v", tt.expectedWaits, clock.waits)
+			}
+		})
+	}
+}
+
+func TestInitialDelay_canceled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := New(Constant{Context: ctx, InitialDelay: time.Hour})
+	if r.Next() {
+		t.Fatal("expected no attempt once the context is canceled during the initial delay")
+	}
+	if r.reason != ContextCanceled {
+		t.Fatalf("expected to stop by %s, actual: %s", ContextCanceled, r.reason)
+	}
+}
+
+func TestInitialDelay_defaultTimeout(t *testing.T) {
+	t.Parallel()
+	r := New(Constant{InitialDelay: time.Hour}, WithClock(&fakeClock{}))
+	if !r.Next() {
+		t.Fatal("expected the first attempt after the initial delay")
+	}
+	deadline, ok := r.ctx.Deadline()
+	if !ok || time.Until(deadline) < time.Hour {
+		t.Fatalf("expected the default timeout to be extended by the initial delay, actual: %v", deadline)
+	}
+}
+
+func TestMaxAttempts_withContext(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		maxAttempts int
+		expected    StopReason
+	}{
+		{
+			name:        "context fires before max attempts",
+			timeout:     30 * time.Millisecond,
+			maxAttempts: 1000,
+			expected:    Timeout,
+		},
+		{
+			name:        "max attempts before context",
+			timeout:     time.Minute,
+			maxAttempts: 3,
+			expected:    MaxAttempts,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			r := New(Constant{
+				Context:     ctx,
+				Interval:    5 * time.Millisecond,
+				MaxAttempts: tt.maxAttempts,
+			})
+			attempts := 0
+			for r.Next() {
+				attempts++
+			}
+			if r.reason != tt.expected {
+				t.Fatalf("expected to stop by %s, actual: %s", tt.expected, r.reason)
+			}
+			if tt.expected == Timeout && attempts >= tt.maxAttempts {
+				t.Fatalf("expected the context to stop before %v attempts, actual: %d", tt.maxAttempts, attempts)
+			}
+			if tt.expected == MaxAttempts && attempts != tt.maxAttempts {
+				t.Fatalf("expected %v attempts, actual: %d", tt.maxAttempts, attempts)
+			}
+			if r.ctx != ctx {
+				t.Fatal("expected the context of the caller to be kept")
+			}
+		})
+	}
+}
+
+// tableBackoff is a Backoff looking up intervals and repeating the last one.
+type tableBackoff []time.Duration
+
+func (b tableBackoff) Calc(attempt int) time.Duration {
+	return b[min(attempt, len(b))-1]
+}
+
+func TestCustom(t *testing.T) {
+	t.Parallel()
+	backoff := tableBackoff{time.Second, 5 * time.Second, -time.Second}
+	tests := []struct {
+		name     string
+		a        Algorithm
+		opts     []Option
+		expected []time.Duration
+	}{
+		{
+			name:     "max attempts",
+			a:        Custom{Backoff: backoff, MaxAttempts: 5},
+			expected: []time.Duration{time.Second, 5 * time.Second, 0, 0},
+		},
+		{
+			name:     "options",
+			a:        Custom{Backoff: backoff},
+			opts:     []Option{WithMaxAttempts(3)},
+			expected: []time.Duration{time.Second, 5 * time.Second},
+		},
+		{
+			name:     "max elapsed time",
+			a:        Custom{Backoff: backoff, MaxElapsedTime: 3 * time.Second},
+			expected: []time.Duration{time.Second, 2 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			clock := &fakeClock{}
+			r := New(tt.a, append(tt.opts, WithClock(clock))...)
+			for r.Next() {
+			}
+			if !reflect.DeepEqual(clock.waits, tt.expected) {
+				t.Fatalf("expected to wait %v, actual: %v", tt.expected, clock.waits)
+			}
+		})
+	}
+}
+
+func TestBackoffFunc(t *testing.T) {
+	t.Parallel()
+	r := New(Custom{Backoff: BackoffFunc(func(attempt int) time.Duration {
+		return time.Duration(attempt) * time.Second
+	})})
+	for i := 1; i <= 3; i++ {
+		if d := r.calc(); d != time.Duration(i)*time.Second {
+			t.Fatalf("calc %d, expected %s, actual: %s", i, time.Duration(i)*time.Second, d)
+		}
+	}
+}
+
+func TestCustom_nilBackoff(t *testing.T) {
+	t.Parallel()
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected New to panic without a Backoff")
+		}
+	}()
+	New(Custom{MaxAttempts: 3})
+}
+
+func TestDeterministic(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		algorithm func(key string) Algorithm
+		expected  []time.Duration
+	}{
+		{
+			name: "jitter",
+			algorithm: func(key string) Algorithm {
+				return Jitter{Key: key, Deterministic: true}
+			},
+			expected: []time.Duration{2 * time.Second, 3500 * time.Millisecond, 5750 * time.Millisecond},
+		},
+		{
+			name: "constant jitter",
+			algorithm: func(key string) Algorithm {
+				return ConstantJitter{Key: key, Jitter: 200 * time.Millisecond, Deterministic: true}
+			},
+			expected: []time.Duration{time.Second, time.Second, time.Second},
+		},
+		{
+			name: "exponential backoff",
+			algorithm: func(key string) Algorithm {
+				return ExponentialBackoff{Key: key, Deterministic: true}
+			},
+			expected: []time.Duration{1500 * time.Millisecond, 3 * time.Second, 6 * time.Second},
+		},
+		{
+			name: "linear jitter",
+			algorithm: func(key string) Algorithm {
+				return LinearJitter{Key: key, Deterministic: true}
+			},
+			expected: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name: "full jitter",
+			algorithm: func(key string) Algorithm {
+				return FullJitter{Key: key, Deterministic: true}
+			},
+			expected: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		},
+		{
+			name: "equal jitter",
+			algorithm: func(key string) Algorithm {
+				return EqualJitter{Key: key, Deterministic: true}
+			},
+			expected: []time.Duration{1500 * time.Millisecond, 3 * time.Second, 6 * time.Second},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			// Different keys would draw different intervals without Deterministic.
+			for _, key := range []string{"a", "b"} {
+				if actual := Preview(tt.algorithm(key), 3); !reflect.DeepEqual(actual, tt.expected) {
+					t.Fatalf("key %q, expected %v, actual: %v", key, tt.expected, actual)
+				}
+			}
+		})
+	}
+}
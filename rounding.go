@@ -0,0 +1,54 @@
+package retry
+
+import "time"
+
+// Rounding is how intervals are aligned to a grid.
+type Rounding int
+
+const (
+	// RoundNone leaves intervals as they are.
+	RoundNone Rounding = iota
+	// RoundUp rounds intervals up to a multiple of the grid,
+	// which guarantees the minimum spacing.
+	RoundUp
+	// RoundNearest rounds intervals to the nearest multiple of the grid.
+	RoundNearest
+	// RoundDown rounds intervals down to a multiple of the grid.
+	RoundDown
+)
+
+// WithRounding aligns every interval to a multiple of grid by mode.
+// RoundNearest and RoundDown never round an interval below one grid
+// to avoid a tight loop without waiting.
+func WithRounding(mode Rounding, grid time.Duration) Option {
+	return func(r *retrier) {
+		r.rounding = mode
+		r.grid = grid
+	}
+}
+
+// round aligns d to a multiple of grid by mode.
+func (mode Rounding) round(d, grid time.Duration) time.Duration {
+	if mode == RoundNone || grid <= 0 {
+		return d
+	}
+	var rounded time.Duration
+	switch mode {
+	case RoundUp:
+		rounded = d.Truncate(grid)
+		if rounded < d {
+			rounded += grid
+		}
+		return rounded
+	case RoundNearest:
+		rounded = d.Round(grid)
+	case RoundDown:
+		rounded = d.Truncate(grid)
+	default:
+		return d
+	}
+	if rounded < grid {
+		return grid
+	}
+	return rounded
+}
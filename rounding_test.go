@@ -0,0 +1,47 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRounding_round(t *testing.T) {
+	t.Parallel()
+	grid := time.Second
+	tests := []struct {
+		name     string
+		mode     Rounding
+		d        time.Duration
+		expected time.Duration
+	}{
+		{name: "none", mode: RoundNone, d: 1400 * time.Millisecond, expected: 1400 * time.Millisecond},
+		{name: "up", mode: RoundUp, d: 1400 * time.Millisecond, expected: 2 * time.Second},
+		{name: "up on the grid", mode: RoundUp, d: 2 * time.Second, expected: 2 * time.Second},
+		{name: "up from zero", mode: RoundUp, d: 0, expected: 0},
+		{name: "nearest down", mode: RoundNearest, d: 1400 * time.Millisecond, expected: time.Second},
+		{name: "nearest up", mode: RoundNearest, d: 1600 * time.Millisecond, expected: 2 * time.Second},
+		{name: "nearest floors at the grid", mode: RoundNearest, d: 400 * time.Millisecond, expected: time.Second},
+		{name: "down", mode: RoundDown, d: 1600 * time.Millisecond, expected: time.Second},
+		{name: "down floors at the grid", mode: RoundDown, d: 600 * time.Millisecond, expected: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.mode.round(tt.d, grid); actual != tt.expected {
+				t.Fatalf("expected %s, actual: %s", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWithRounding(t *testing.T) {
+	t.Parallel()
+	r := New(ConstantJitter{
+		Interval: 10 * time.Second,
+		Jitter:   5 * time.Second,
+	}, WithRounding(RoundUp, time.Second))
+	for i := 0; i < 100; i++ {
+		if d := r.interval(); d%time.Second != 0 {
+			t.Fatalf("expected interval %d to be aligned to a second, actual: %s", i, d)
+		}
+	}
+}
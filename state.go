@@ -0,0 +1,227 @@
+package retry
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrInvalidState is returned when LoadState is given a malformed state.
+var ErrInvalidState = errors.New("retry: invalid state")
+
+// stateVersion is the first byte of a state to detect incompatible formats.
+const stateVersion byte = 3
+
+// stateful is implemented by calculators that carry state between intervals.
+type stateful interface {
+	state() []byte
+	loadState(b []byte) error
+}
+
+// State serializes the number of attempts and the internal state of the algorithm,
+// e.g. the previous interval and the seed of Jitter, to checkpoint a long retry loop.
+//
+// The format is stable: a version byte, a byte identifying the algorithm, the number of attempts as
+// a big-endian uint64 followed by the algorithm specific state.
+func (r *retrier) State() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b := make([]byte, 10, 34)
+	b[0] = stateVersion
+	b[1] = algorithmKind(r.algorithm)
+	binary.BigEndian.PutUint64(b[2:], uint64(r.attempts))
+	if c, ok := r.calculator.(stateful); ok {
+		b = append(b, c.state()...)
+	}
+	return b
+}
+
+// LoadState restores a state serialized by State so that
+// the retrier continues the backoff curve where it left off.
+// The retrier must be created from the same algorithm as the one which produced the state,
+// otherwise LoadState returns ErrInvalidState. Restored attempts count against MaxAttempts,
+// so a retrier with fewer MaxAttempts than the restored attempts stops at the next call of Next.
+// MaxElapsedTime counts from the call since the state carries no time.
+func (r *retrier) LoadState(b []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(b) < 10 || b[0] != stateVersion || b[1] != algorithmKind(r.algorithm) {
+		return ErrInvalidState
+	}
+	if c, ok := r.calculator.(stateful); ok {
+		if err := c.loadState(b[10:]); err != nil {
+			return err
+		}
+	} else if len(b) != 10 {
+		return ErrInvalidState
+	}
+	r.attempts = int(binary.BigEndian.Uint64(b[2:]))
+	r.started = r.timeNow()
+	return nil
+}
+
+// algorithmKind identifies the algorithm which produced a state, so that LoadState rejects a state of another one.
+func algorithmKind(a Algorithm) byte {
+	switch a.(type) {
+	case Jitter:
+		return 1
+	case Constant:
+		return 2
+	case ConstantJitter:
+		return 3
+	case ExponentialBackoff:
+		return 4
+	case Linear:
+		return 5
+	case LinearJitter:
+		return 6
+	case Fibonacci:
+		return 7
+	case FullJitter:
+		return 8
+	case EqualJitter:
+		return 9
+	case Adaptive:
+		return 10
+	case Custom:
+		return 11
+	}
+	return 0
+}
+
+func (j *Jitter) state() []byte {
+	b := binary.BigEndian.AppendUint64(nil, uint64(j.attempt))
+	b = binary.BigEndian.AppendUint64(b, uint64(j.interval))
+	return binary.BigEndian.AppendUint64(b, uint64(j.seed))
+}
+
+func (j *Jitter) loadState(b []byte) error {
+	if len(b) != 24 {
+		return ErrInvalidState
+	}
+	j.attempt = int(binary.BigEndian.Uint64(b))
+	j.interval = time.Duration(binary.BigEndian.Uint64(b[8:]))
+	j.seed = int64(binary.BigEndian.Uint64(b[16:]))
+	return nil
+}
+
+func (c *ConstantJitter) state() []byte {
+	b := binary.BigEndian.AppendUint64(nil, uint64(c.attempt))
+	return binary.BigEndian.AppendUint64(b, uint64(c.seed))
+}
+
+func (c *ConstantJitter) loadState(b []byte) error {
+	if len(b) != 16 {
+		return ErrInvalidState
+	}
+	c.attempt = int(binary.BigEndian.Uint64(b))
+	c.seed = int64(binary.BigEndian.Uint64(b[8:]))
+	return nil
+}
+
+func (b *ExponentialBackoff) state() []byte {
+	s := binary.BigEndian.AppendUint64(nil, uint64(b.attempt))
+	return binary.BigEndian.AppendUint64(s, uint64(b.seed))
+}
+
+func (b *ExponentialBackoff) loadState(s []byte) error {
+	if len(s) != 16 {
+		return ErrInvalidState
+	}
+	b.attempt = int(binary.BigEndian.Uint64(s))
+	b.seed = int64(binary.BigEndian.Uint64(s[8:]))
+	return nil
+}
+
+func (l *Linear) state() []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(l.attempt))
+}
+
+func (l *Linear) loadState(b []byte) error {
+	if len(b) != 8 {
+		return ErrInvalidState
+	}
+	l.attempt = int(binary.BigEndian.Uint64(b))
+	return nil
+}
+
+func (a *Adaptive) state() []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(a.latency))
+}
+
+func (a *Adaptive) loadState(b []byte) error {
+	if len(b) != 8 {
+		return ErrInvalidState
+	}
+	a.latency = time.Duration(binary.BigEndian.Uint64(b))
+	return nil
+}
+
+func (c *Custom) state() []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(c.attempt))
+}
+
+func (c *Custom) loadState(b []byte) error {
+	if len(b) != 8 {
+		return ErrInvalidState
+	}
+	c.attempt = int(binary.BigEndian.Uint64(b))
+	return nil
+}
+
+func (l *LinearJitter) state() []byte {
+	b := binary.BigEndian.AppendUint64(nil, uint64(l.attempt))
+	return binary.BigEndian.AppendUint64(b, uint64(l.seed))
+}
+
+func (l *LinearJitter) loadState(b []byte) error {
+	if len(b) != 16 {
+		return ErrInvalidState
+	}
+	l.attempt = int(binary.BigEndian.Uint64(b))
+	l.seed = int64(binary.BigEndian.Uint64(b[8:]))
+	return nil
+}
+
+func (f *Fibonacci) state() []byte {
+	b := binary.BigEndian.AppendUint64(nil, math.Float64bits(f.prev))
+	return binary.BigEndian.AppendUint64(b, math.Float64bits(f.cur))
+}
+
+func (f *Fibonacci) loadState(b []byte) error {
+	if len(b) != 16 {
+		return ErrInvalidState
+	}
+	f.prev = math.Float64frombits(binary.BigEndian.Uint64(b))
+	f.cur = math.Float64frombits(binary.BigEndian.Uint64(b[8:]))
+	return nil
+}
+
+func (f *FullJitter) state() []byte {
+	b := binary.BigEndian.AppendUint64(nil, uint64(f.attempt))
+	return binary.BigEndian.AppendUint64(b, uint64(f.seed))
+}
+
+func (f *FullJitter) loadState(b []byte) error {
+	if len(b) != 16 {
+		return ErrInvalidState
+	}
+	f.attempt = int(binary.BigEndian.Uint64(b))
+	f.seed = int64(binary.BigEndian.Uint64(b[8:]))
+	return nil
+}
+
+func (e *EqualJitter) state() []byte {
+	b := binary.BigEndian.AppendUint64(nil, uint64(e.attempt))
+	return binary.BigEndian.AppendUint64(b, uint64(e.seed))
+}
+
+func (e *EqualJitter) loadState(b []byte) error {
+	if len(b) != 16 {
+		return ErrInvalidState
+	}
+	e.attempt = int(binary.BigEndian.Uint64(b))
+	e.seed = int64(binary.BigEndian.Uint64(b[8:]))
+	return nil
+}
@@ -0,0 +1,148 @@
+package retry
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRetrier_LoadState(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		algorithm Algorithm
+	}{
+		{
+			name:      "constant",
+			algorithm: Constant{Interval: time.Millisecond},
+		},
+		{
+			name: "jitter",
+			algorithm: Jitter{
+				Base: time.Millisecond,
+				Max:  time.Hour,
+			},
+		},
+		{
+			name:      "constant jitter",
+			algorithm: ConstantJitter{Interval: time.Millisecond},
+		},
+		{
+			name:      "linear",
+			algorithm: Linear{Base: time.Millisecond},
+		},
+		{
+			name:      "linear jitter",
+			algorithm: LinearJitter{Base: time.Millisecond},
+		},
+		{
+			name:      "fibonacci",
+			algorithm: Fibonacci{Base: time.Millisecond},
+		},
+		{
+			name:      "full jitter",
+			algorithm: FullJitter{Base: time.Millisecond},
+		},
+		{
+			name:      "equal jitter",
+			algorithm: EqualJitter{Base: time.Millisecond},
+		},
+		{
+			name:      "adaptive",
+			algorithm: Adaptive{Base: time.Millisecond},
+		},
+		{
+			name:      "custom",
+			algorithm: Custom{Backoff: tableBackoff{time.Millisecond, 2 * time.Millisecond}},
+		},
+		{
+			name: "exponential backoff",
+			algorithm: ExponentialBackoff{
+				Base: time.Millisecond,
+				Max:  time.Hour,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := New(tt.algorithm)
+			for i := 0; i < 3; i++ {
+				r.calc()
+				r.Fire()
+			}
+			restored := New(tt.algorithm)
+			if err := restored.LoadState(r.State()); err != nil {
+				t.Fatal(err)
+			}
+			if restored.attempts != r.attempts {
+				t.Fatalf("expected %v attempts, actual: %v", r.attempts, restored.attempts)
+			}
+			if !reflect.DeepEqual(restored.calculator, r.calculator) {
+				t.Fatalf("expected %#v, actual: %#v", r.calculator, restored.calculator)
+			}
+		})
+	}
+}
+
+func TestRetrier_LoadState_invalid(t *testing.T) {
+	t.Parallel()
+	r := New(ExponentialBackoff{})
+	valid := r.State()
+	for _, b := range [][]byte{
+		nil,
+		{stateVersion},
+		append([]byte{stateVersion + 1}, valid[1:]...),
+		New(Constant{}).State(),
+		// The same length as a state of ExponentialBackoff.
+		New(ConstantJitter{}).State(),
+	} {
+		if err := r.LoadState(b); !errors.Is(err, ErrInvalidState) {
+			t.Fatalf("expected %v for %v, actual: %v", ErrInvalidState, b, err)
+		}
+	}
+}
+
+func TestRetrier_LoadState_maxElapsedTime(t *testing.T) {
+	t.Parallel()
+	clock := &fakeClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	a := Constant{Interval: time.Second, MaxElapsedTime: 3 * time.Second}
+	saved := New(a, WithClock(clock))
+	saved.Next()
+	saved.Next()
+	r := New(a, WithClock(clock))
+	if err := r.LoadState(saved.State()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := r.Elapsed(); elapsed != 0 {
+		t.Fatalf("expected the elapsed time to count from LoadState, actual: %s", elapsed)
+	}
+	attempts := 0
+	for r.Next() {
+		attempts++
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 more attempts within MaxElapsedTime, actual: %d", attempts)
+	}
+	if r.StopReason() != MaxElapsed {
+		t.Fatalf("expected to stop by %s, actual: %s", MaxElapsed, r.StopReason())
+	}
+}
+
+func TestRetrier_LoadState_maxAttempts(t *testing.T) {
+	t.Parallel()
+	saved := New(Constant{Interval: time.Microsecond, MaxAttempts: 10})
+	for i := 0; i < 5; i++ {
+		saved.Next()
+	}
+	r := New(Constant{Interval: time.Microsecond, MaxAttempts: 3})
+	if err := r.LoadState(saved.State()); err != nil {
+		t.Fatal(err)
+	}
+	if r.Next() {
+		t.Fatal("expected no attempt beyond MaxAttempts")
+	}
+	if r.StopReason() != MaxAttempts {
+		t.Fatalf("expected to stop by %s, actual: %s", MaxAttempts, r.StopReason())
+	}
+}
@@ -0,0 +1,61 @@
+package retry
+
+import "time"
+
+// Stats describes the intervals a retrier waited between attempts, e.g. to tune Base and Max
+// by the actual spread of a jittered algorithm rather than eyeballing logs.
+type Stats struct {
+	// Count is the number of intervals waited.
+	Count int
+	// Min is the shortest interval.
+	Min time.Duration
+	// Max is the longest interval.
+	Max time.Duration
+	// Mean is the average interval.
+	Mean time.Duration
+	// Decreases is how many times an interval was shorter than the previous one.
+	Decreases int
+}
+
+// intervalStats accumulates Stats.
+type intervalStats struct {
+	Stats
+	sum  time.Duration
+	last time.Duration
+}
+
+func (s *intervalStats) record(d time.Duration) {
+	if s.Count == 0 || d < s.Min {
+		s.Min = d
+	}
+	if s.Count == 0 || s.Max < d {
+		s.Max = d
+	}
+	if 0 < s.Count && d < s.last {
+		s.Decreases++
+	}
+	s.Count++
+	s.sum += d
+	s.Mean = s.sum / time.Duration(s.Count)
+	s.last = d
+}
+
+// WithStats makes the retrier track the intervals it waits, see Stats.
+// Tracking is disabled by default not to cost anything.
+func WithStats() Option {
+	return func(r *retrier) {
+		r.stats = &intervalStats{}
+	}
+}
+
+// Stats returns the statistics of the intervals waited so far.
+// The initial delay is not included since the algorithm does not compute it.
+// It returns the zero Stats unless WithStats is given.
+func (r *retrier) Stats() Stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stats == nil {
+		return Stats{}
+	}
+	return r.stats.Stats
+}
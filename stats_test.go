@@ -0,0 +1,51 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithStats(t *testing.T) {
+	t.Parallel()
+	backoff := tableBackoff{2 * time.Second, time.Second, 3 * time.Second, 2 * time.Second}
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected Stats
+	}{
+		{
+			name: "stats",
+			opts: []Option{WithStats()},
+			expected: Stats{
+				Count:     4,
+				Min:       time.Second,
+				Max:       3 * time.Second,
+				Mean:      2 * time.Second,
+				Decreases: 2,
+			},
+		},
+		{
+			name: "disabled",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := New(Custom{
+				Backoff:      backoff,
+				InitialDelay: time.Hour,
+				MaxAttempts:  5,
+			}, append(tt.opts, WithClock(&fakeClock{}))...)
+			for r.Next() {
+			}
+			if actual := r.Stats(); actual != tt.expected {
+				t.Fatalf("expected %+v, actual: %+v", tt.expected, actual)
+			}
+			r.Reset()
+			if actual := r.Stats(); actual != (Stats{}) {
+				t.Fatalf("expected Reset to clear the stats, actual: %+v", actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package retry
+
+import (
+	"context"
+	"errors"
+)
+
+// StopReason describes why a retry loop stopped.
+type StopReason int
+
+const (
+	// Running means the retry loop has not stopped yet.
+	Running StopReason = iota
+	// Success means the retried operation succeeded.
+	Success
+	// MaxAttempts means the retry loop reached the maximum number of attempts.
+	MaxAttempts
+	// Timeout means the context, including the default timeout, reached its deadline.
+	Timeout
+	// ContextCanceled means the context was canceled.
+	ContextCanceled
+	// Denied means a Guard denied a retry.
+	Denied
+	// Deadline means the retry loop reached the Deadline of the algorithm.
+	Deadline
+	// ProbeFailed means the probe failed before the first attempt.
+	ProbeFailed
+	// NotRetryable means the error returned by the function must not be retried.
+	NotRetryable
+	// MaxElapsed means MaxElapsedTime of the algorithm has elapsed since the first attempt.
+	MaxElapsed
+	// BreakerOpen means the circuit breaker given by WithBreaker refused an attempt.
+	BreakerOpen
+	// SleepFailed means the sleep function given by WithSleep returned an error
+	// while the context was not done.
+	SleepFailed
+)
+
+func (s StopReason) String() string {
+	switch s {
+	case Running:
+		return "running"
+	case Success:
+		return "success"
+	case MaxAttempts:
+		return "max attempts"
+	case Timeout:
+		return "timeout"
+	case ContextCanceled:
+		return "context canceled"
+	case Denied:
+		return "denied"
+	case Deadline:
+		return "deadline"
+	case ProbeFailed:
+		return "probe failed"
+	case NotRetryable:
+		return "not retryable"
+	case MaxElapsed:
+		return "max elapsed time"
+	case BreakerOpen:
+		return "breaker open"
+	case SleepFailed:
+		return "sleep failed"
+	}
+	return "unknown"
+}
+
+// contextReason returns the reason why ctx is done.
+func contextReason(ctx context.Context) StopReason {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return Timeout
+	}
+	return ContextCanceled
+}
+
+// StopReason returns why Next returned false, e.g. to tell a canceled context from exhausted attempts.
+// It is set the moment Next returns false and stays Running until then,
+// including when the caller leaves the loop by itself, e.g. on success.
+func (r *retrier) StopReason() StopReason {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reason
+}
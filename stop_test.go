@@ -0,0 +1,64 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRetrier_StopReason(t *testing.T) {
+	t.Parallel()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	tests := []struct {
+		name     string
+		a        Algorithm
+		expected StopReason
+	}{
+		{
+			name:     "max attempts",
+			a:        Constant{MaxAttempts: 3},
+			expected: MaxAttempts,
+		},
+		{
+			name:     "context canceled",
+			a:        Constant{Context: canceled},
+			expected: ContextCanceled,
+		},
+		{
+			name:     "timeout",
+			a:        Constant{Context: expired},
+			expected: Timeout,
+		},
+		{
+			name:     "deadline",
+			a:        Constant{Deadline: time.Time{}.Add(3 * time.Second)},
+			expected: Deadline,
+		},
+		{
+			name:     "max elapsed time",
+			a:        Constant{MaxElapsedTime: 3 * time.Second},
+			expected: MaxElapsed,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := New(tt.a, WithClock(&fakeClock{}))
+			if reason := r.StopReason(); reason != Running {
+				t.Fatalf("expected %s before the loop, actual: %s", Running, reason)
+			}
+			for r.Next() {
+				if reason := r.StopReason(); reason != Running {
+					t.Fatalf("expected %s within the loop, actual: %s", Running, reason)
+				}
+			}
+			if reason := r.StopReason(); reason != tt.expected {
+				t.Fatalf("expected %s, actual: %s", tt.expected, reason)
+			}
+		})
+	}
+}
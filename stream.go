@@ -0,0 +1,65 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// defaultHealthyPeriod is how long a stream must stay up to be considered healthy.
+const defaultHealthyPeriod = time.Minute
+
+// WithHealthyPeriod sets how long a stream must stay up before Stream considers it healthy
+// and starts the backoff over on the next failure. Default is 1 minute.
+func WithHealthyPeriod(d time.Duration) Option {
+	return func(r *retrier) {
+		r.healthyPeriod = d
+	}
+}
+
+// Stream keeps a stream, e.g. a gRPC streaming call, alive by reconnecting with backoff.
+// It calls connect to establish a stream and passes it to handle.
+// When either of them fails, Stream reconnects after the interval of the algorithm.
+// If the stream stayed up for the healthy period before failing,
+// Stream reconnects immediately and the backoff starts over.
+//
+// ctx is passed to connect and bounds the retry loop unless the algorithm has its own Context.
+// Stream returns nil once handle returns nil, otherwise the last error when it gives up.
+func Stream[S any](ctx context.Context, a Algorithm, connect func(ctx context.Context) (S, error), handle func(S) error, opts ...Option) error {
+	r := New(a, opts...)
+	defer r.end()
+	if r.ctx == nil {
+		WithContext(ctx)(r)
+	}
+	if r.healthyPeriod == 0 {
+		r.healthyPeriod = defaultHealthyPeriod
+	}
+	var err error
+	for r.Next() {
+		var s S
+		s, err = connect(ctx)
+		if err != nil {
+			continue
+		}
+		start := r.timeNow()
+		err = handle(s)
+		if err == nil {
+			return nil
+		}
+		if r.healthyPeriod <= r.since(start) {
+			r.restart()
+		}
+	}
+	if err == nil {
+		// The loop stopped before the first attempt, e.g. the context is done during InitialDelay.
+		return r.wrapContextErr(nil)
+	}
+	return err
+}
+
+// restart makes the retrier behave as if no attempt has been made.
+func (r *retrier) restart() {
+	r.mu.Lock()
+	r.attempts = 0
+	r.mu.Unlock()
+	r.Progress()
+}
@@ -0,0 +1,104 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+	base := 20 * time.Millisecond
+	healthy := 50 * time.Millisecond
+	errBroken := errors.New("broken")
+	var (
+		lastEnd time.Time
+		gaps    []time.Duration
+		handled int
+	)
+	connect := func(ctx context.Context) (int, error) {
+		if !lastEnd.IsZero() {
+			gaps = append(gaps, time.Since(lastEnd))
+		}
+		return handled, nil
+	}
+	handle := func(n int) error {
+		defer func() {
+			handled++
+			lastEnd = time.Now()
+		}()
+		switch n {
+		case 3:
+			time.Sleep(healthy + 10*time.Millisecond)
+			return errBroken
+		case 5:
+			return nil
+		default:
+			return errBroken
+		}
+	}
+	err := Stream(context.Background(), ExponentialBackoff{
+		Base: base,
+		Max:  time.Second,
+	}, connect, handle, WithHealthyPeriod(healthy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("gaps: %v", gaps)
+	if len(gaps) != 5 {
+		t.Fatalf("expected to reconnect 5 times, actual: %d", len(gaps))
+	}
+	for i, least := range []time.Duration{base, 2 * base, 4 * base} {
+		if gaps[i] < least {
+			t.Fatalf("expected reconnect %d to back off %s at least, actual: %s", i, least, gaps[i])
+		}
+	}
+	if base <= gaps[3] {
+		t.Fatalf("expected to reconnect immediately after a healthy stream, actual: %s", gaps[3])
+	}
+	if gaps[4] < base || 3*base < gaps[4] {
+		t.Fatalf("expected the backoff to start over after a healthy stream, actual: %s", gaps[4])
+	}
+}
+
+func TestStream_giveUp(t *testing.T) {
+	t.Parallel()
+	errConnect := errors.New("connect")
+	connects := 0
+	err := Stream(context.Background(), Constant{
+		Interval:    time.Millisecond,
+		MaxAttempts: 3,
+	}, func(ctx context.Context) (int, error) {
+		connects++
+		return 0, errConnect
+	}, func(int) error {
+		t.Fatal("expected not to handle a stream which failed to connect")
+		return nil
+	})
+	if !errors.Is(err, errConnect) {
+		t.Fatalf("expected %v, actual: %v", errConnect, err)
+	}
+	if connects != 3 {
+		t.Fatalf("expected to connect 3 times, actual: %d", connects)
+	}
+}
+
+func TestStream_canceledBeforeFirstAttempt(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	connected := 0
+	err := Stream(ctx, Constant{InitialDelay: time.Hour}, func(ctx context.Context) (int, error) {
+		connected++
+		return 0, nil
+	}, func(int) error {
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %v, actual: %v", context.Canceled, err)
+	}
+	if connected != 0 {
+		t.Fatalf("expected no connection, actual: %d", connected)
+	}
+}
@@ -0,0 +1,162 @@
+package retry
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// String returns the algorithm with defaults applied, e.g. Jitter(base=1s, max=15s, maxAttempts=5).
+func (j Jitter) String() string {
+	j = j.withDefaults()
+	return describe("Jitter", limits{j.MaxAttempts, j.MaxElapsedTime, j.InitialDelay, j.Deadline},
+		"base="+j.Base.String(),
+		"max="+j.Max.String(),
+	)
+}
+
+// String returns the algorithm with defaults applied, e.g. Constant(interval=1s, maxAttempts=5).
+func (c Constant) String() string {
+	c = c.withDefaults()
+	return describe("Constant", limits{c.MaxAttempts, c.MaxElapsedTime, c.InitialDelay, c.Deadline},
+		"interval="+c.Interval.String(),
+	)
+}
+
+// String returns the algorithm with defaults applied,
+// e.g. ConstantJitter(interval=1s, jitter=500ms, mode=symmetric, maxAttempts=5).
+func (c ConstantJitter) String() string {
+	c = c.withDefaults()
+	return describe("ConstantJitter", limits{c.MaxAttempts, c.MaxElapsedTime, c.InitialDelay, c.Deadline},
+		"interval="+c.Interval.String(),
+		"jitter="+c.Jitter.String(),
+		"mode="+c.Mode.String(),
+	)
+}
+
+// String returns the algorithm with defaults applied,
+// e.g. ExponentialBackoff(base=1s, max=15s, min=1s, maxAttempts=5).
+func (b ExponentialBackoff) String() string {
+	b = b.withDefaults()
+	fields := []string{
+		"base=" + b.Base.String(),
+		"max=" + b.Max.String(),
+		"min=" + b.Min.String(),
+	}
+	if b.MaxDoublings > 0 {
+		fields = append(fields, fmt.Sprintf("maxDoublings=%d", b.MaxDoublings))
+	}
+	if b.PhaseJitter {
+		fields = append(fields, "phaseJitter=true")
+	}
+	if b.StartAtBase {
+		fields = append(fields, "startAtBase=true")
+	}
+	return describe("ExponentialBackoff", limits{b.MaxAttempts, b.MaxElapsedTime, b.InitialDelay, b.Deadline}, fields...)
+}
+
+// String returns the algorithm with defaults applied,
+// e.g. Linear(base=1s, increment=1s, max=15s, maxAttempts=5).
+func (l Linear) String() string {
+	l = l.withDefaults()
+	return describe("Linear", limits{l.MaxAttempts, l.MaxElapsedTime, l.InitialDelay, l.Deadline},
+		"base="+l.Base.String(),
+		"increment="+l.Increment.String(),
+		"max="+l.Max.String(),
+	)
+}
+
+// String returns the algorithm with defaults applied,
+// e.g. LinearJitter(base=1s, increment=1s, spread=500ms, max=15s, maxAttempts=5).
+func (l LinearJitter) String() string {
+	l = l.withDefaults()
+	return describe("LinearJitter", limits{l.MaxAttempts, l.MaxElapsedTime, l.InitialDelay, l.Deadline},
+		"base="+l.Base.String(),
+		"increment="+l.Increment.String(),
+		"spread="+l.Spread.String(),
+		"max="+l.Max.String(),
+	)
+}
+
+// String returns the algorithm with defaults applied, e.g. Fibonacci(base=1s, max=15s, maxAttempts=5).
+func (f Fibonacci) String() string {
+	f = f.withDefaults()
+	return describe("Fibonacci", limits{f.MaxAttempts, f.MaxElapsedTime, f.InitialDelay, f.Deadline},
+		"base="+f.Base.String(),
+		"max="+f.Max.String(),
+	)
+}
+
+// String returns the algorithm with defaults applied, e.g. FullJitter(base=1s, max=15s, maxAttempts=5).
+func (f FullJitter) String() string {
+	f = f.withDefaults()
+	return describe("FullJitter", limits{f.MaxAttempts, f.MaxElapsedTime, f.InitialDelay, f.Deadline},
+		"base="+f.Base.String(),
+		"max="+f.Max.String(),
+	)
+}
+
+// String returns the algorithm with defaults applied, e.g. EqualJitter(base=1s, max=15s, maxAttempts=5).
+func (e EqualJitter) String() string {
+	e = e.withDefaults()
+	return describe("EqualJitter", limits{e.MaxAttempts, e.MaxElapsedTime, e.InitialDelay, e.Deadline},
+		"base="+e.Base.String(),
+		"max="+e.Max.String(),
+	)
+}
+
+// String returns the algorithm with defaults applied,
+// e.g. Adaptive(base=1s, multiplier=2, max=15s, maxAttempts=5).
+func (a Adaptive) String() string {
+	a = a.withDefaults()
+	return describe("Adaptive", limits{a.MaxAttempts, a.MaxElapsedTime, a.InitialDelay, a.Deadline},
+		"base="+a.Base.String(),
+		fmt.Sprintf("multiplier=%g", a.Multiplier),
+		"max="+a.Max.String(),
+	)
+}
+
+// String returns the algorithm, e.g. Custom(backoff=retry.BackoffFunc, maxAttempts=5).
+func (c Custom) String() string {
+	return describe("Custom", limits{c.MaxAttempts, c.MaxElapsedTime, c.InitialDelay, c.Deadline},
+		fmt.Sprintf("backoff=%T", c.Backoff),
+	)
+}
+
+// String returns the name of the mode.
+func (m JitterMode) String() string {
+	switch m {
+	case Symmetric:
+		return "symmetric"
+	case PositiveOnly:
+		return "positiveOnly"
+	default:
+		return fmt.Sprintf("JitterMode(%d)", int(m))
+	}
+}
+
+// limits are the options shared by every algorithm to stop retrying.
+type limits struct {
+	maxAttempts  int
+	maxElapsed   time.Duration
+	initialDelay time.Duration
+	deadline     time.Time
+}
+
+// describe formats an algorithm as name(fields...) followed by the limits that are set,
+// leaving out the state of the loop, the context and the source of randomness.
+func describe(name string, l limits, fields ...string) string {
+	if l.maxAttempts != 0 {
+		fields = append(fields, fmt.Sprintf("maxAttempts=%d", l.maxAttempts))
+	}
+	if l.maxElapsed != 0 {
+		fields = append(fields, "maxElapsedTime="+l.maxElapsed.String())
+	}
+	if l.initialDelay != 0 {
+		fields = append(fields, "initialDelay="+l.initialDelay.String())
+	}
+	if !l.deadline.IsZero() {
+		fields = append(fields, "deadline="+l.deadline.Format(time.RFC3339))
+	}
+	return name + "(" + strings.Join(fields, ", ") + ")"
+}
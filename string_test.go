@@ -0,0 +1,87 @@
+package retry
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestString(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name      string
+		algorithm fmt.Stringer
+		expected  string
+	}{
+		{
+			name:      "Jitter",
+			algorithm: Jitter{MaxAttempts: 5},
+			expected:  "Jitter(base=1s, max=15s, maxAttempts=5)",
+		},
+		{
+			name:      "Constant",
+			algorithm: Constant{Interval: 2 * time.Second, MaxElapsedTime: time.Minute},
+			expected:  "Constant(interval=2s, maxElapsedTime=1m0s)",
+		},
+		{
+			name:      "ConstantJitter",
+			algorithm: ConstantJitter{Mode: PositiveOnly},
+			expected:  "ConstantJitter(interval=1s, jitter=500ms, mode=positiveOnly)",
+		},
+		{
+			name:      "ExponentialBackoff",
+			algorithm: ExponentialBackoff{Base: 100 * time.Millisecond, MaxDoublings: 3, InitialDelay: time.Second},
+			expected:  "ExponentialBackoff(base=100ms, max=15s, min=100ms, maxDoublings=3, initialDelay=1s)",
+		},
+		{
+			name:      "ExponentialBackoff start at base",
+			algorithm: ExponentialBackoff{StartAtBase: true},
+			expected:  "ExponentialBackoff(base=1s, max=15s, min=500ms, startAtBase=true)",
+		},
+		{
+			name:      "Linear",
+			algorithm: Linear{Deadline: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+			expected:  "Linear(base=1s, increment=1s, max=15s, deadline=2024-01-02T03:04:05Z)",
+		},
+		{
+			name:      "LinearJitter",
+			algorithm: LinearJitter{Increment: 2 * time.Second},
+			expected:  "LinearJitter(base=1s, increment=2s, spread=500ms, max=15s)",
+		},
+		{
+			name:      "Fibonacci",
+			algorithm: Fibonacci{},
+			expected:  "Fibonacci(base=1s, max=15s)",
+		},
+		{
+			name:      "FullJitter",
+			algorithm: FullJitter{},
+			expected:  "FullJitter(base=1s, max=15s)",
+		},
+		{
+			name:      "EqualJitter",
+			algorithm: EqualJitter{MaxAttempts: 3},
+			expected:  "EqualJitter(base=1s, max=15s, maxAttempts=3)",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if actual := tt.algorithm.String(); actual != tt.expected {
+				t.Fatalf("expected %q, actual: %q", tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestString_excludesState(t *testing.T) {
+	t.Parallel()
+	b := &ExponentialBackoff{MaxAttempts: 5}
+	before := b.String()
+	b.calc()
+	b.calc()
+	if after := b.String(); after != before {
+		t.Fatalf("expected the state of the loop to be excluded, before: %q, after: %q", before, after)
+	}
+}
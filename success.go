@@ -0,0 +1,80 @@
+package retry
+
+import "sync"
+
+// SuccessTracker tracks the fraction of Do calls that succeeded instead of exhausting retries,
+// e.g. to raise an early warning when retries of an operation almost always fail.
+// Use one tracker per operation. It keeps the latest outcomes within a window
+// and is safe for concurrent use.
+type SuccessTracker struct {
+	mu       sync.Mutex
+	outcomes []bool
+	// next is the index of outcomes to overwrite once the window is full.
+	next      int
+	threshold float64
+	onBelow   func(rate float64)
+}
+
+// NewSuccessTracker creates a SuccessTracker which keeps the latest window outcomes.
+func NewSuccessTracker(window int) *SuccessTracker {
+	if window < 1 {
+		window = 1
+	}
+	return &SuccessTracker{
+		outcomes: make([]bool, 0, window),
+	}
+}
+
+// OnBelow calls fn with the success rate whenever an outcome is recorded
+// while the window is full and the rate is below threshold, e.g. 0.5 for 50%.
+func (s *SuccessTracker) OnBelow(threshold float64, fn func(rate float64)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.threshold = threshold
+	s.onBelow = fn
+}
+
+// Record adds an outcome.
+func (s *SuccessTracker) Record(succeeded bool) {
+	s.mu.Lock()
+	if len(s.outcomes) < cap(s.outcomes) {
+		s.outcomes = append(s.outcomes, succeeded)
+	} else {
+		s.outcomes[s.next] = succeeded
+		s.next = (s.next + 1) % len(s.outcomes)
+	}
+	full := len(s.outcomes) == cap(s.outcomes)
+	rate := s.rate()
+	threshold, onBelow := s.threshold, s.onBelow
+	s.mu.Unlock()
+	if full && onBelow != nil && rate < threshold {
+		onBelow(rate)
+	}
+}
+
+// Rate returns the fraction of succeeded outcomes. It returns 1 if there are no outcomes.
+func (s *SuccessTracker) Rate() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rate()
+}
+
+func (s *SuccessTracker) rate() float64 {
+	if len(s.outcomes) == 0 {
+		return 1
+	}
+	succeeded := 0
+	for _, ok := range s.outcomes {
+		if ok {
+			succeeded++
+		}
+	}
+	return float64(succeeded) / float64(len(s.outcomes))
+}
+
+// WithSuccessTracker records whether every Do call succeeded to s.
+func WithSuccessTracker(s *SuccessTracker) Option {
+	return func(r *retrier) {
+		r.success = s
+	}
+}
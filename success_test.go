@@ -0,0 +1,56 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuccessTracker_Rate(t *testing.T) {
+	t.Parallel()
+	s := NewSuccessTracker(4)
+	if rate := s.Rate(); rate != 1 {
+		t.Fatalf("expected 1 without outcomes, actual: %v", rate)
+	}
+	var warned []float64
+	s.OnBelow(0.6, func(rate float64) {
+		warned = append(warned, rate)
+	})
+	tests := []struct {
+		succeeded bool
+		expected  float64
+	}{
+		{succeeded: true, expected: 1},
+		{succeeded: false, expected: 0.5},
+		{succeeded: true, expected: 2.0 / 3},
+		{succeeded: true, expected: 0.75},
+		// The window slides from here on.
+		{succeeded: false, expected: 0.5},
+		{succeeded: false, expected: 0.5},
+		{succeeded: true, expected: 0.5},
+		{succeeded: true, expected: 0.5},
+		{succeeded: true, expected: 0.75},
+	}
+	for i, tt := range tests {
+		s.Record(tt.succeeded)
+		if actual := s.Rate(); actual != tt.expected {
+			t.Fatalf("outcome #%d, expected the success rate to be %v, actual: %v", i+1, tt.expected, actual)
+		}
+	}
+	// The early failure is not warned before the window is full.
+	if len(warned) != 4 {
+		t.Fatalf("expected 4 warnings, actual: %v", warned)
+	}
+}
+
+func TestWithSuccessTracker(t *testing.T) {
+	t.Parallel()
+	s := NewSuccessTracker(10)
+	a := Constant{Interval: time.Microsecond, MaxAttempts: 2}
+	for i := 0; i < 4; i++ {
+		_ = Do(a, failN(1, errTest), WithSuccessTracker(s))
+	}
+	_ = Do(a, failN(2, errTest), WithSuccessTracker(s))
+	if rate := s.Rate(); rate != 0.8 {
+		t.Fatalf("expected the success rate to be 0.8, actual: %v", rate)
+	}
+}